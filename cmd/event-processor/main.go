@@ -2,18 +2,46 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/nivedita-verma/event-processor/internal/app/eventprocessor"
+	"github.com/nivedita-verma/event-processor/internal/pkg/clientregistry"
+	"github.com/nivedita-verma/event-processor/internal/pkg/eventqueue"
 	"github.com/nivedita-verma/event-processor/internal/pkg/eventstore"
+	"github.com/nivedita-verma/event-processor/internal/pkg/fieldcrypt"
+	"github.com/nivedita-verma/event-processor/internal/pkg/quota"
+	"github.com/nivedita-verma/event-processor/internal/pkg/redact"
+	"github.com/nivedita-verma/event-processor/internal/pkg/rules"
+	"github.com/nivedita-verma/event-processor/internal/pkg/signing"
 	"github.com/nivedita-verma/event-processor/internal/pkg/vars"
+	"github.com/nivedita-verma/event-processor/pkg/eventspec"
 	"go.uber.org/zap"
 )
 
 func main() {
+	pollQueueURL := flag.String("poll", os.Getenv(vars.PollQueueURLEnvVar), "SQS queue URL to long-poll instead of running as a Lambda function")
+	batchSize := flag.Int("batch-size", 10, "most messages handled at once when polling, from 1 to 10")
+	retryDelay := flag.Duration("retry-delay", 30*time.Second, "how long a failed message stays invisible before redelivery when polling")
+	httpAddr := flag.String("http", os.Getenv(vars.HTTPAddrEnvVar), "address to serve the HTTP ingress on, e.g. :8080, instead of running as a Lambda function")
+	filePath := flag.String("file", "", "file of one JSON event per line to ingest once, or - for standard input, instead of running as a Lambda function")
+	flag.Parse()
+
 	logger, err := zap.NewDevelopment()
 	if err != nil {
 		panic(err)
@@ -26,7 +54,363 @@ func main() {
 		panic(err)
 	}
 	client := dynamodb.NewFromConfig(cfg)
-	store := eventstore.NewDynamoDBStore(client, os.Getenv(vars.TableNameEnvVar), logger.Sugar())
-	handler := eventprocessor.NewHandler(logger.Sugar(), eventprocessor.NewService(store))
-	lambda.Start(handler.HandleSQSEvent)
+	redactor, err := loadRedactor()
+	if err != nil {
+		panic(err)
+	}
+	storeOpts := []eventstore.StoreOption{eventstore.WithRedactor(redactor)}
+	if detectConflicts, _ := strconv.ParseBool(os.Getenv(vars.DetectConflictsEnvVar)); detectConflicts {
+		storeOpts = append(storeOpts, eventstore.WithConflictDetection())
+	}
+	store := eventstore.NewDynamoDBStore(client, os.Getenv(vars.TableNameEnvVar), logger.Sugar(), storeOpts...)
+	quarantine := eventstore.NewDynamoDBQuarantine(client, os.Getenv(vars.QuarantineTableNameEnvVar), logger.Sugar())
+	schemas, err := eventspec.NewSchemaRegistry()
+	if err != nil {
+		panic(err)
+	}
+	handlerOpts := []eventprocessor.HandlerOption{
+		eventprocessor.WithEnvelopeMode(envelopeMode()),
+		eventprocessor.WithQuarantine(quarantine),
+		eventprocessor.WithProcessorVersion(vars.Version),
+		eventprocessor.WithRedactor(redactor),
+	}
+	if concurrency, err := strconv.Atoi(os.Getenv(vars.ProcessingConcurrencyEnvVar)); err == nil {
+		handlerOpts = append(handlerOpts, eventprocessor.WithConcurrency(concurrency))
+	}
+	if orderByClient, _ := strconv.ParseBool(os.Getenv(vars.OrderByClientEnvVar)); orderByClient {
+		handlerOpts = append(handlerOpts, eventprocessor.WithClientOrdering())
+	}
+	if batchWrites, _ := strconv.ParseBool(os.Getenv(vars.BatchWritesEnvVar)); batchWrites {
+		handlerOpts = append(handlerOpts, eventprocessor.WithBatchWrites())
+	}
+	registry, err := clientRegistry(client, logger.Sugar())
+	if err != nil {
+		panic(err)
+	}
+	if clientChecks, _ := strconv.ParseBool(os.Getenv(vars.ClientChecksEnvVar)); clientChecks {
+		if registry == nil {
+			panic(fmt.Sprintf("%s needs %s to be set", vars.ClientChecksEnvVar, vars.ClientTableEnvVar))
+		}
+		handlerOpts = append(handlerOpts, eventprocessor.WithClientChecks(registry))
+	}
+	if verifySignatures, _ := strconv.ParseBool(os.Getenv(vars.SignatureVerificationEnvVar)); verifySignatures {
+		if registry == nil {
+			panic(fmt.Sprintf("%s needs %s to be set", vars.SignatureVerificationEnvVar, vars.ClientTableEnvVar))
+		}
+		// Every event of a source whose events cannot be signed would be
+		// rejected
+		if source := os.Getenv(vars.LambdaEventSourceEnvVar); source == "kinesis" || source == "eventbridge" {
+			panic(fmt.Sprintf("%s cannot be set for %s=%s, whose events cannot be signed", vars.SignatureVerificationEnvVar, vars.LambdaEventSourceEnvVar, source))
+		}
+		handlerOpts = append(handlerOpts, eventprocessor.WithSignatureVerification(signing.NewRegistryKeyProvider(registry)))
+	}
+	serviceOpts, routeNames, err := routes(os.Getenv(vars.RouteTablesEnvVar), func(tableName string) eventstore.Api {
+		return eventstore.NewDynamoDBStore(client, tableName, logger.Sugar(), storeOpts...)
+	})
+	if err != nil {
+		panic(err)
+	}
+	fastPathQueueURL := os.Getenv(vars.FastPathQueueURLEnvVar)
+	if fastPathQueueURL != "" {
+		publisher := eventqueue.NewSQSPublisher(sqs.NewFromConfig(cfg), fastPathQueueURL, logger.Sugar())
+		serviceOpts = append(serviceOpts, eventprocessor.WithFastPath(eventprocessor.PublishTo(publisher)))
+		routeNames = append(routeNames, string(eventprocessor.FastPathRoute))
+	}
+	pipeline, err := stages(os.Getenv(vars.PipelineStagesEnvVar), map[string]func() (eventprocessor.Stage, error){
+		"drop-persisted": func() (eventprocessor.Stage, error) { return eventprocessor.DropPersisted(store), nil },
+		"metrics":        func() (eventprocessor.Stage, error) { return eventprocessor.RecordMetrics(logger.Sugar()), nil },
+		"priority": func() (eventprocessor.Stage, error) {
+			return eventprocessor.Prioritise(eventprocessor.DefaultPriorityRules()), nil
+		},
+		"fast-path": func() (eventprocessor.Stage, error) {
+			if fastPathQueueURL == "" {
+				return nil, fmt.Errorf("%s is not set", vars.FastPathQueueURLEnvVar)
+			}
+			return eventprocessor.RouteCritical(), nil
+		},
+		"rules": func() (eventprocessor.Stage, error) {
+			ruleSet, err := loadRules(ctx, client, routeNames)
+			if err != nil {
+				return nil, err
+			}
+			return eventprocessor.ApplyRules(ruleSet), nil
+		},
+		"enrich": func() (eventprocessor.Stage, error) {
+			if registry == nil {
+				return nil, fmt.Errorf("%s is not set", vars.ClientTableEnvVar)
+			}
+			return eventprocessor.Enrich(registry), nil
+		},
+		"quota": func() (eventprocessor.Stage, error) { return quotaStage(client, logger.Sugar()) },
+		"encrypt": func() (eventprocessor.Stage, error) {
+			encryptor, err := loadEncryptor(kms.NewFromConfig(cfg))
+			if err != nil {
+				return nil, err
+			}
+			return eventprocessor.EncryptFields(encryptor), nil
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+	serviceOpts = append(serviceOpts, eventprocessor.WithStages(pipeline...))
+	handler := eventprocessor.NewHandler(logger.Sugar(), eventprocessor.NewService(store, serviceOpts...), schemas, handlerOpts...)
+	var ingressOpts []eventprocessor.HTTPIngressOption
+	if queueURL := os.Getenv(vars.IngressQueueURLEnvVar); queueURL != "" {
+		ingressOpts = append(ingressOpts, eventprocessor.WithSender(eventqueue.NewSQSSender(sqs.NewFromConfig(cfg), queueURL, logger.Sugar())))
+	}
+	ingress := eventprocessor.NewHTTPIngress(handler, ingressOpts...)
+
+	if *pollQueueURL == "" && *httpAddr == "" && *filePath == "" {
+		switch os.Getenv(vars.LambdaEventSourceEnvVar) {
+		case "apigateway":
+			lambda.Start(ingress.HandleAPIGatewayProxyRequest)
+		case "kinesis":
+			lambda.Start(handler.HandleKinesisEvent)
+		case "sns":
+			lambda.Start(handler.HandleSNSEvent)
+		case "eventbridge":
+			lambda.Start(handler.HandleEventBridgeEvent)
+		default:
+			lambda.Start(handler.HandleSQSEvent)
+		}
+		return
+	}
+
+	// Outside Lambda, finish the work in hand and stop on SIGTERM, as sent by
+	// container runtimes, or on Ctrl+C
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGTERM, os.Interrupt)
+	defer stop()
+	if *filePath != "" {
+		if err := ingestFile(ctx, handler, *filePath, logger.Sugar()); err != nil {
+			panic(err)
+		}
+		return
+	}
+	if *pollQueueURL != "" {
+		eventqueue.NewPoller(sqs.NewFromConfig(cfg), *pollQueueURL, handler.HandleSQSEvent, logger.Sugar(),
+			eventqueue.WithBatchSize(*batchSize),
+			eventqueue.WithRetryDelay(*retryDelay),
+		).Run(ctx)
+		return
+	}
+	serve(ctx, *httpAddr, ingress, logger.Sugar())
+}
+
+// ingestFile ingests the events of a file, or of standard input for "-", and
+// fails if any event could not be ingested and should be sent again.
+func ingestFile(ctx context.Context, handler *eventprocessor.Handler, path string, logger *zap.SugaredLogger) error {
+	var file io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		file = f
+	}
+	results, err := handler.IngestFile(ctx, path, file)
+	var rejected, retry int
+	for _, result := range results {
+		switch {
+		case result.Retry:
+			retry++
+		case result.Err != nil:
+			rejected++
+		}
+	}
+	logger.Infof("Ingested %d events of %s: %d accepted, %d rejected, %d to send again", len(results), path, len(results)-rejected-retry, rejected, retry)
+	if err != nil {
+		return err
+	}
+	if retry > 0 {
+		return fmt.Errorf("%d events of %s could not be ingested and should be sent again", retry, path)
+	}
+	return nil
+}
+
+// serve runs the HTTP ingress on POST /events until the context is
+// cancelled, then waits for requests in flight to finish.
+func serve(ctx context.Context, addr string, ingress http.Handler, logger *zap.SugaredLogger) {
+	mux := http.NewServeMux()
+	mux.Handle("/events", ingress)
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			logger.Errorf("failed to shut down HTTP server: %v", err)
+		}
+	}()
+
+	logger.Infof("Listening for events on %s", addr)
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		panic(err)
+	}
+	<-stopped
+	logger.Info("Stopped listening for events")
+}
+
+func envelopeMode() eventprocessor.EnvelopeMode {
+	if os.Getenv(vars.EnvelopeValidationModeEnvVar) == string(eventprocessor.EnvelopeModeStrict) {
+		return eventprocessor.EnvelopeModeStrict
+	}
+	return eventprocessor.EnvelopeModeLenient
+}
+
+// loadRedactor masks the paths of the REDACTION_POLICY, a JSON object of
+// event type to paths, or of the default policy when it is not set.
+func loadRedactor() (*redact.Redactor, error) {
+	config := os.Getenv(vars.RedactionPolicyEnvVar)
+	if config == "" {
+		return redact.New(redact.DefaultPolicy())
+	}
+	var policy redact.Policy
+	if err := json.Unmarshal([]byte(config), &policy); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", vars.RedactionPolicyEnvVar, err)
+	}
+	return redact.New(policy)
+}
+
+// loadEncryptor encrypts the paths of the ENCRYPTION_POLICY, a JSON object of
+// event type to paths, with data keys generated under the KMS key
+// FIELD_ENCRYPTION_KEY_ID. Without a policy the paths redacted from logs are
+// encrypted.
+func loadEncryptor(client *kms.Client) (*fieldcrypt.Encryptor, error) {
+	keyID := os.Getenv(vars.FieldEncryptionKeyEnvVar)
+	if keyID == "" {
+		return nil, fmt.Errorf("%s is not set", vars.FieldEncryptionKeyEnvVar)
+	}
+	config := os.Getenv(vars.EncryptionPolicyEnvVar)
+	if config == "" {
+		config = os.Getenv(vars.RedactionPolicyEnvVar)
+	}
+	policy := fieldcrypt.Policy(redact.DefaultPolicy())
+	if config != "" {
+		policy = fieldcrypt.Policy{}
+		if err := json.Unmarshal([]byte(config), &policy); err != nil {
+			return nil, fmt.Errorf("invalid encryption policy: %w", err)
+		}
+	}
+	return fieldcrypt.New(fieldcrypt.NewKMSKeyProvider(client, keyID), policy)
+}
+
+// routes parses a comma separated list of type=table pairs into routes that
+// persist events of each type to their own table, returning the names of the
+// routes with them.
+func routes(config string, newStore func(tableName string) eventstore.Api) ([]eventprocessor.ServiceOption, []string, error) {
+	var opts []eventprocessor.ServiceOption
+	var names []string
+	for _, route := range strings.Split(config, ",") {
+		if strings.TrimSpace(route) == "" {
+			continue
+		}
+		eventType, tableName, ok := strings.Cut(strings.TrimSpace(route), "=")
+		if !ok || tableName == "" {
+			return nil, nil, fmt.Errorf("invalid route %q, expected type=table", route)
+		}
+		if !eventspec.IsValidEventType(eventType) {
+			return nil, nil, fmt.Errorf("invalid route %q: unsupported event type %s", route, eventType)
+		}
+		opts = append(opts, eventprocessor.WithRoute(eventspec.EventType(eventType), eventprocessor.PersistTo(newStore(tableName))))
+		names = append(names, eventType)
+	}
+	return opts, names, nil
+}
+
+// loadRules loads the triage rules from the rules table or, when no table is
+// set, the rules file. Route rules may only name the given routes.
+func loadRules(ctx context.Context, client *dynamodb.Client, routes []string) (*rules.RuleSet, error) {
+	var loaded []rules.Rule
+	var err error
+	switch {
+	case os.Getenv(vars.RulesTableEnvVar) != "":
+		loaded, err = rules.LoadDynamoDB(ctx, client, os.Getenv(vars.RulesTableEnvVar))
+	case os.Getenv(vars.RulesFileEnvVar) != "":
+		loaded, err = rules.LoadFile(os.Getenv(vars.RulesFileEnvVar))
+	default:
+		return nil, fmt.Errorf("neither %s nor %s is set", vars.RulesTableEnvVar, vars.RulesFileEnvVar)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rules.NewRuleSet(loaded, rules.WithRoutes(routes...))
+}
+
+// defaultClientCacheTTL is how long clients are cached when CLIENT_CACHE_TTL is
+// not set.
+const defaultClientCacheTTL = 5 * time.Minute
+
+// clientRegistry returns the client table, cached for CLIENT_CACHE_TTL, or nil
+// when no table is set.
+func clientRegistry(client *dynamodb.Client, logger *zap.SugaredLogger) (clientregistry.Api, error) {
+	tableName := os.Getenv(vars.ClientTableEnvVar)
+	if tableName == "" {
+		return nil, nil
+	}
+	ttl := defaultClientCacheTTL
+	if config := os.Getenv(vars.ClientCacheTTLEnvVar); config != "" {
+		var err error
+		if ttl, err = time.ParseDuration(config); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", vars.ClientCacheTTLEnvVar, err)
+		}
+	}
+	return clientregistry.NewCachedRegistry(clientregistry.NewDynamoDBRegistry(client, tableName, logger), ttl), nil
+}
+
+// quotaStage enforces the quota configured by the QUOTA_* variables, keeping
+// buckets in the quota table.
+func quotaStage(client *dynamodb.Client, logger *zap.SugaredLogger) (eventprocessor.Stage, error) {
+	tableName := os.Getenv(vars.QuotaTableEnvVar)
+	if tableName == "" {
+		return nil, fmt.Errorf("%s is not set", vars.QuotaTableEnvVar)
+	}
+	rate, err := strconv.ParseFloat(os.Getenv(vars.QuotaRateEnvVar), 64)
+	if err != nil || rate <= 0 {
+		return nil, fmt.Errorf("invalid %s %q, expected events per second", vars.QuotaRateEnvVar, os.Getenv(vars.QuotaRateEnvVar))
+	}
+	burst, err := strconv.Atoi(os.Getenv(vars.QuotaBurstEnvVar))
+	if err != nil || burst < 1 {
+		return nil, fmt.Errorf("invalid %s %q, expected a positive number of events", vars.QuotaBurstEnvVar, os.Getenv(vars.QuotaBurstEnvVar))
+	}
+	perType, _ := strconv.ParseBool(os.Getenv(vars.QuotaPerTypeEnvVar))
+	mode := eventprocessor.QuotaMode(os.Getenv(vars.QuotaModeEnvVar))
+	switch mode {
+	case eventprocessor.QuotaModeRetry, eventprocessor.QuotaModeQuarantine, eventprocessor.QuotaModeFlag:
+	case "":
+		mode = eventprocessor.QuotaModeRetry
+	default:
+		return nil, fmt.Errorf("invalid %s %q", vars.QuotaModeEnvVar, mode)
+	}
+	limiter := quota.NewDynamoDBLimiter(client, tableName, logger)
+	return eventprocessor.EnforceQuota(limiter, eventprocessor.Quota{
+		Limit:   quota.Limit{Rate: rate, Burst: burst},
+		PerType: perType,
+		Mode:    mode,
+	}), nil
+}
+
+// stages builds the pipeline from a comma separated list of stage names, run
+// in the order given. Only the stages named are built.
+func stages(config string, available map[string]func() (eventprocessor.Stage, error)) ([]eventprocessor.Stage, error) {
+	var pipeline []eventprocessor.Stage
+	for _, name := range strings.Split(config, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		newStage, ok := available[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown pipeline stage %q", name)
+		}
+		stage, err := newStage()
+		if err != nil {
+			return nil, fmt.Errorf("pipeline stage %q: %w", name, err)
+		}
+		pipeline = append(pipeline, stage)
+	}
+	return pipeline, nil
 }
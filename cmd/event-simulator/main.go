@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"crypto/ed25519"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -13,6 +15,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
 	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 	"github.com/nivedita-verma/event-processor/pkg/eventspec"
@@ -37,6 +40,10 @@ func main() {
 	if err != nil {
 		log.Fatalf("unable to load AWS SDK config: %v", err)
 	}
+	signer, err := signer(os.Getenv("SIGNING_ALGORITHM"), os.Getenv("SIGNING_KEY"))
+	if err != nil {
+		log.Fatalf("invalid signing key: %v", err)
+	}
 	client := sqs.New(sqs.Options{
 		Region:           cfg.Region,
 		Credentials:      cfg.Credentials,
@@ -55,14 +62,12 @@ func main() {
 
 		var msgBody string
 		if isValid {
+			eventType := eventspec.ValidEventTypes[rand.Intn(len(eventspec.ValidEventTypes))]
 			event := eventspec.Event{
 				EventID:  uuid.NewString(),
 				ClientID: fmt.Sprintf("client-%d", rand.Intn(5)+1),
-				Type:     string(eventspec.ValidEventTypes[rand.Intn(len(eventspec.ValidEventTypes))]),
-				Data: map[string]interface{}{
-					"value": rand.Intn(1000),
-					"time":  time.Now().String(),
-				},
+				Type:     string(eventType),
+				Data:     validDataGenerator(eventType),
 			}
 			b, _ := json.Marshal(event)
 			msgBody = string(b)
@@ -72,10 +77,17 @@ func main() {
 			msgBody = string(b)
 		}
 
-		_, err := client.SendMessage(context.TODO(), &sqs.SendMessageInput{
+		input := &sqs.SendMessageInput{
 			QueueUrl:    aws.String(queueURL),
 			MessageBody: aws.String(msgBody),
-		})
+		}
+		if signer != nil {
+			input.MessageAttributes = make(map[string]types.MessageAttributeValue)
+			for name, value := range signer.Sign([]byte(msgBody)) {
+				input.MessageAttributes[name] = types.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(value)}
+			}
+		}
+		_, err := client.SendMessage(context.TODO(), input)
 		if err != nil {
 			log.Printf("failed to send message: %v", err)
 		} else {
@@ -90,6 +102,32 @@ func main() {
 	}
 }
 
+// signer signs events with the base64 key given, an HMAC-SHA256 secret or an
+// Ed25519 private key or seed depending on the algorithm. Without a key events
+// are sent unsigned.
+func signer(algorithm, encodedKey string) (*eventspec.Signer, error) {
+	if encodedKey == "" {
+		return nil, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil {
+		return nil, err
+	}
+	switch eventspec.SignatureAlgorithm(algorithm) {
+	case eventspec.SignatureHMACSHA256, "":
+		return eventspec.NewHMACSigner(key), nil
+	case eventspec.SignatureEd25519:
+		switch len(key) {
+		case ed25519.SeedSize:
+			return eventspec.NewEd25519Signer(ed25519.NewKeyFromSeed(key)), nil
+		case ed25519.PrivateKeySize:
+			return eventspec.NewEd25519Signer(key), nil
+		}
+		return nil, fmt.Errorf("Ed25519 key must be %d or %d bytes", ed25519.SeedSize, ed25519.PrivateKeySize)
+	}
+	return nil, fmt.Errorf("unsupported signing algorithm %q", algorithm)
+}
+
 func getEnv(key, fallback string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -97,6 +135,32 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+func validDataGenerator(eventType eventspec.EventType) map[string]interface{} {
+	switch eventType {
+	case eventspec.MonitoringAlert:
+		severities := []string{"critical", "high", "medium", "low"}
+		return map[string]interface{}{
+			"severity": severities[rand.Intn(len(severities))],
+			"message":  fmt.Sprintf("CPU usage above threshold at %s", time.Now().Format(time.RFC3339)),
+			"metric":   "cpu_utilisation",
+			"value":    rand.Intn(100),
+		}
+	case eventspec.Transaction:
+		return map[string]interface{}{
+			"transactionId": uuid.NewString(),
+			"amount":        rand.Intn(20000),
+			"currency":      "GBP",
+			"status":        "completed",
+		}
+	default:
+		return map[string]interface{}{
+			"title":   "Reminder",
+			"message": fmt.Sprintf("Scheduled reminder sent at %s", time.Now().Format(time.RFC3339)),
+			"channel": "email",
+		}
+	}
+}
+
 func invalidEventGenerator() eventspec.Event {
 	invalidEvents := []eventspec.Event{
 		// Invalid event type
@@ -105,6 +169,8 @@ func invalidEventGenerator() eventspec.Event {
 		{EventID: "", ClientID: fmt.Sprintf("client-%d", rand.Intn(5)+1), Type: "monitoringAlert", Data: map[string]interface{}{}},
 		{EventID: uuid.NewString(), ClientID: "", Type: "notification", Data: map[string]interface{}{}},
 		{EventID: uuid.NewString(), ClientID: uuid.NewString(), Type: "transaction", Data: nil},
+		// Data not matching the schema for its type
+		{EventID: uuid.NewString(), ClientID: fmt.Sprintf("client-%d", rand.Intn(5)+1), Type: "transaction", Data: map[string]interface{}{"currency": "GBP"}},
 		// Empty Event
 		{},
 	}
@@ -0,0 +1,139 @@
+// Command rules-dry-run evaluates triage rules against sample events and
+// reports which rules fire for each, without processing anything.
+//
+//	rules-dry-run -rules deployment/rules.example.yaml -events events.json
+//
+// Rules are read from a JSON or YAML file, or with -table from a DynamoDB
+// table. Events are read from a file holding a JSON array of events or one
+// event per line, or from standard input when no file is given.
+//
+// Route rules may only name the routes given with -routes, as the processor
+// fails to start with rules naming a route it has not registered. These are
+// fastPath and the event types of ROUTE_TABLES, e.g.
+// -routes fastPath,transaction.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/nivedita-verma/event-processor/internal/app/eventprocessor"
+	"github.com/nivedita-verma/event-processor/internal/pkg/rules"
+	"github.com/nivedita-verma/event-processor/pkg/eventspec"
+)
+
+func main() {
+	rulesFile := flag.String("rules", "", "JSON or YAML file of rules")
+	table := flag.String("table", "", "DynamoDB table of rules, used instead of -rules")
+	eventsFile := flag.String("events", "", "file of sample events, defaults to standard input")
+	routes := flag.String("routes", string(eventprocessor.FastPathRoute), "comma separated routes registered by the processor")
+	flag.Parse()
+
+	ctx := context.Background()
+	var loaded []rules.Rule
+	var err error
+	switch {
+	case *table != "":
+		cfg, cfgErr := config.LoadDefaultConfig(ctx)
+		if cfgErr != nil {
+			log.Fatalf("unable to load AWS SDK config: %v", cfgErr)
+		}
+		loaded, err = rules.LoadDynamoDB(ctx, dynamodb.NewFromConfig(cfg), *table)
+	case *rulesFile != "":
+		loaded, err = rules.LoadFile(*rulesFile)
+	default:
+		log.Fatal("one of -rules or -table must be set")
+	}
+	if err != nil {
+		log.Fatalf("failed to load rules: %v", err)
+	}
+	var routeNames []string
+	for _, route := range strings.Split(*routes, ",") {
+		if route = strings.TrimSpace(route); route != "" {
+			routeNames = append(routeNames, route)
+		}
+	}
+	ruleSet, err := rules.NewRuleSet(loaded, rules.WithRoutes(routeNames...))
+	if err != nil {
+		log.Fatalf("invalid rules:\n%v", err)
+	}
+
+	input := os.Stdin
+	if *eventsFile != "" {
+		if input, err = os.Open(*eventsFile); err != nil {
+			log.Fatalf("failed to open events: %v", err)
+		}
+		defer input.Close()
+	}
+	events, err := readEvents(input)
+	if err != nil {
+		log.Fatalf("failed to read events: %v", err)
+	}
+
+	fired := 0
+	for _, event := range events {
+		matched := ruleSet.Match(event)
+		fmt.Printf("%s (client %s, type %s): %d rule(s) fired\n", event.EventID, event.ClientID, event.Type, len(matched))
+		for _, rule := range matched {
+			if rule.Value != "" {
+				fmt.Printf("  %s: %s %s\n", rule.Name, rule.Action, rule.Value)
+			} else {
+				fmt.Printf("  %s: %s\n", rule.Name, rule.Action)
+			}
+			if rule.Action == rules.ActionDrop {
+				fmt.Println("  (later rules are not applied to dropped events)")
+				break
+			}
+		}
+		if len(matched) > 0 {
+			fired++
+		}
+	}
+	fmt.Printf("%d of %d event(s) matched at least one of %d rule(s)\n", fired, len(events), len(loaded))
+}
+
+// readEvents reads either a JSON array of events or a stream of events, one
+// after another.
+func readEvents(r io.Reader) ([]eventspec.Event, error) {
+	reader := bufio.NewReader(r)
+	start, err := reader.Peek(1)
+	for err == nil && len(bytes.TrimSpace(start)) == 0 {
+		reader.ReadByte()
+		start, err = reader.Peek(1)
+	}
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(reader)
+	var events []eventspec.Event
+	if start[0] == '[' {
+		err := decoder.Decode(&events)
+		return events, err
+	}
+	for {
+		var event eventspec.Event
+		err := decoder.Decode(&event)
+		if errors.Is(err, io.EOF) {
+			return events, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("event %d: %w", len(events)+1, err)
+		}
+		events = append(events, event)
+	}
+}
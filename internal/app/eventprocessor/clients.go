@@ -0,0 +1,30 @@
+package eventprocessor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nivedita-verma/event-processor/internal/pkg/clientregistry"
+	"github.com/nivedita-verma/event-processor/pkg/eventspec"
+)
+
+// checkClient checks that the event's client is onboarded, active and allowed
+// to send events of its type, returning the violations found. Only failing to
+// read the registry is returned as an error.
+func checkClient(ctx context.Context, registry clientregistry.Api, event eventspec.Event) (eventspec.ValidationErrors, error) {
+	client, err := registry.Get(ctx, event.ClientID)
+	if errors.Is(err, clientregistry.ErrClientNotFound) {
+		return eventspec.ValidationErrors{{Code: eventspec.CodeUnknownClient, Pointer: "/clientId", Message: fmt.Sprintf("unknown client: %s", event.ClientID)}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client %s: %w", event.ClientID, err)
+	}
+	if !client.Active {
+		return eventspec.ValidationErrors{{Code: eventspec.CodeClientSuspended, Pointer: "/clientId", Message: fmt.Sprintf("client is suspended: %s", event.ClientID)}}, nil
+	}
+	if event.Type != "" && !client.Allows(event.Type) {
+		return eventspec.ValidationErrors{{Code: eventspec.CodeTypeNotAllowed, Pointer: "/type", Message: fmt.Sprintf("client %s may not send events of type %s", event.ClientID, event.Type)}}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,61 @@
+package eventprocessor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nivedita-verma/event-processor/internal/pkg/clientregistry"
+	"github.com/nivedita-verma/event-processor/pkg/eventspec"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_checkClient(t *testing.T) {
+	registry := clientregistry.NewMemoryRegistry(
+		clientregistry.Client{ClientID: "client-1", Active: true, AllowedEventTypes: []string{"notification", "transaction"}},
+		clientregistry.Client{ClientID: "client-2", Active: false, AllowedEventTypes: []string{"notification"}},
+	)
+	cases := []struct {
+		name     string
+		event    eventspec.Event
+		expected eventspec.ValidationErrors
+	}{
+		{
+			name:  "an allowed event of an active client",
+			event: eventspec.Event{ClientID: "client-1", Type: "transaction"},
+		},
+		{
+			name:     "an event of an unknown client",
+			event:    eventspec.Event{ClientID: "client-3", Type: "notification"},
+			expected: eventspec.ValidationErrors{{Code: eventspec.CodeUnknownClient, Pointer: "/clientId", Message: "unknown client: client-3"}},
+		},
+		{
+			name:     "an event of a suspended client",
+			event:    eventspec.Event{ClientID: "client-2", Type: "notification"},
+			expected: eventspec.ValidationErrors{{Code: eventspec.CodeClientSuspended, Pointer: "/clientId", Message: "client is suspended: client-2"}},
+		},
+		{
+			name:     "an event of a type the client may not send",
+			event:    eventspec.Event{ClientID: "client-1", Type: "monitoringAlert"},
+			expected: eventspec.ValidationErrors{{Code: eventspec.CodeTypeNotAllowed, Pointer: "/type", Message: "client client-1 may not send events of type monitoringAlert"}},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run("when checking "+c.name, func(t *testing.T) {
+			errs, err := checkClient(context.Background(), registry, c.event)
+
+			t.Run("should return the expected violations", func(t *testing.T) {
+				assert.NoError(t, err)
+				assert.Equal(t, c.expected, errs)
+			})
+		})
+	}
+
+	t.Run("when the registry cannot be read", func(t *testing.T) {
+		_, err := checkClient(context.Background(), &mockRegistry{err: assert.AnError}, eventspec.Event{ClientID: "client-1"})
+
+		t.Run("should return the error", func(t *testing.T) {
+			assert.ErrorIs(t, err, assert.AnError)
+		})
+	})
+}
@@ -0,0 +1,83 @@
+package eventprocessor
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/nivedita-verma/event-processor/pkg/eventspec"
+)
+
+// processConcurrently handles envelopes on up to h.concurrency workers and
+// returns their results in order.
+//
+// With client ordering enabled, envelopes for the same client form a lane that
+// is handled sequentially in batch order. Once an envelope in a lane is left
+// for redelivery the rest of the lane is too, so a later event cannot overtake
+// it.
+func (h *Handler) processConcurrently(ctx context.Context, envelopes []Envelope, events []*eventspec.Event) []Result {
+	results := make([]Result, len(envelopes))
+	workers := make(chan struct{}, h.concurrency)
+	var wg sync.WaitGroup
+
+	for _, lane := range h.lanes(envelopes) {
+		workers <- struct{}{}
+		wg.Add(1)
+		go func(lane []int) {
+			defer wg.Done()
+			defer func() { <-workers }()
+
+			blocked := false
+			for _, i := range lane {
+				if blocked {
+					h.logger.Warnf("Message ID %s deferred until an earlier message for the same client succeeds", envelopes[i].MessageID)
+					results[i] = Result{MessageID: envelopes[i].MessageID, EventID: eventIDOf(envelopes[i].Body), Err: errDeferred, Retry: true}
+					continue
+				}
+				results[i] = h.ingestEnvelope(ctx, envelopes, events, i)
+				blocked = h.orderByClient && results[i].Retry
+			}
+		}(lane)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// lanes groups envelope indexes into units of work that must run sequentially.
+// Without client ordering every envelope is its own lane.
+func (h *Handler) lanes(envelopes []Envelope) [][]int {
+	var lanes [][]int
+	laneByClient := make(map[string]int)
+	for i, envelope := range envelopes {
+		clientID := ""
+		if h.orderByClient {
+			clientID = clientIDOf(envelope.Body)
+		}
+		if clientID == "" {
+			lanes = append(lanes, []int{i})
+			continue
+		}
+
+		lane, ok := laneByClient[clientID]
+		if !ok {
+			lane = len(lanes)
+			laneByClient[clientID] = lane
+			lanes = append(lanes, nil)
+		}
+		lanes[lane] = append(lanes[lane], i)
+	}
+	return lanes
+}
+
+// clientIDOf extracts the client ID from a body, returning an empty
+// string if the body cannot be decoded.
+func clientIDOf(body string) string {
+	var envelope struct {
+		ClientID string `json:"clientId"`
+	}
+	if err := json.Unmarshal([]byte(body), &envelope); err != nil {
+		return ""
+	}
+	return envelope.ClientID
+}
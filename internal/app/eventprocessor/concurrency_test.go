@@ -0,0 +1,151 @@
+package eventprocessor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/nivedita-verma/event-processor/internal/pkg/eventstore"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type serviceFunc func(context.Context, eventstore.Record) error
+
+func (f serviceFunc) Process(ctx context.Context, record eventstore.Record) error {
+	return f(ctx, record)
+}
+
+func notificationBody(eventID, clientID string) string {
+	return fmt.Sprintf(`{"eventId":"%s","clientId":"%s","type":"notification","data":{"message":"hello"}}`, eventID, clientID)
+}
+
+func Test_NewHandler_WithConcurrency(t *testing.T) {
+	t.Run("should use the given concurrency", func(t *testing.T) {
+		handler := NewHandler(zap.NewNop().Sugar(), &mockService{}, newSchemaRegistry(t), WithConcurrency(4))
+		assert.Equal(t, 4, handler.concurrency)
+	})
+
+	t.Run("should process at least one message at a time", func(t *testing.T) {
+		handler := NewHandler(zap.NewNop().Sugar(), &mockService{}, newSchemaRegistry(t), WithConcurrency(0))
+		assert.Equal(t, 1, handler.concurrency)
+	})
+}
+
+func Test_Handler_lanes(t *testing.T) {
+	envelopes := []Envelope{
+		{Body: notificationBody("1", "client-1")},
+		{Body: notificationBody("2", "client-2")},
+		{Body: "not json"},
+		{Body: notificationBody("3", "client-1")},
+	}
+
+	t.Run("when client ordering is disabled", func(t *testing.T) {
+		handler := NewHandler(zap.NewNop().Sugar(), &mockService{}, newSchemaRegistry(t))
+
+		t.Run("should put every message in its own lane", func(t *testing.T) {
+			assert.Equal(t, [][]int{{0}, {1}, {2}, {3}}, handler.lanes(envelopes))
+		})
+	})
+
+	t.Run("when client ordering is enabled", func(t *testing.T) {
+		handler := NewHandler(zap.NewNop().Sugar(), &mockService{}, newSchemaRegistry(t), WithClientOrdering())
+
+		t.Run("should group messages by client in batch order", func(t *testing.T) {
+			assert.Equal(t, [][]int{{0, 3}, {1}, {2}}, handler.lanes(envelopes))
+		})
+	})
+}
+
+func Test_Handler_HandleSQSEvent_Concurrently(t *testing.T) {
+	t.Run("when concurrency is greater than one", func(t *testing.T) {
+		var inFlight, maxInFlight int32
+		started := make(chan struct{}, 3)
+		release := make(chan struct{})
+		service := serviceFunc(func(ctx context.Context, record eventstore.Record) error {
+			current := atomic.AddInt32(&inFlight, 1)
+			defer atomic.AddInt32(&inFlight, -1)
+			for {
+				observed := atomic.LoadInt32(&maxInFlight)
+				if current <= observed || atomic.CompareAndSwapInt32(&maxInFlight, observed, current) {
+					break
+				}
+			}
+			started <- struct{}{}
+			<-release
+			if record.EventID == "2" {
+				return assert.AnError
+			}
+			return nil
+		})
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t), WithConcurrency(3))
+		sqsEvent := createSQSEvent([]string{
+			notificationBody("1", "client-1"),
+			notificationBody("2", "client-2"),
+			notificationBody("3", "client-3"),
+		})
+
+		var response events.SQSEventResponse
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			response, _ = handler.HandleSQSEvent(context.Background(), sqsEvent)
+		}()
+		for range 3 {
+			select {
+			case <-started:
+			case <-time.After(5 * time.Second):
+				t.Fatal("messages were not processed concurrently")
+			}
+		}
+		close(release)
+		wg.Wait()
+
+		t.Run("then messages should be processed in parallel", func(t *testing.T) {
+			assert.Equal(t, int32(3), maxInFlight)
+		})
+
+		t.Run("then the response should include only the failed message", func(t *testing.T) {
+			assert.Equal(t, []events.SQSBatchItemFailure{{ItemIdentifier: "msg-2"}}, response.BatchItemFailures)
+		})
+	})
+
+	t.Run("when client ordering is enabled and a message fails", func(t *testing.T) {
+		var mu sync.Mutex
+		var processed []string
+		service := serviceFunc(func(ctx context.Context, record eventstore.Record) error {
+			mu.Lock()
+			processed = append(processed, record.EventID)
+			mu.Unlock()
+			if record.EventID == "1" {
+				return assert.AnError
+			}
+			return nil
+		})
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t), WithConcurrency(2), WithClientOrdering())
+		sqsEvent := createSQSEvent([]string{
+			notificationBody("1", "client-1"),
+			notificationBody("2", "client-2"),
+			notificationBody("3", "client-1"),
+		})
+
+		response, err := handler.HandleSQSEvent(context.Background(), sqsEvent)
+
+		t.Run("then there should be no error", func(t *testing.T) {
+			assert.NoError(t, err)
+		})
+
+		t.Run("then later messages for the same client should not be processed", func(t *testing.T) {
+			assert.ElementsMatch(t, []string{"1", "2"}, processed)
+		})
+
+		t.Run("then the failed message and those queued behind it should be redelivered", func(t *testing.T) {
+			assert.Equal(t, []events.SQSBatchItemFailure{{ItemIdentifier: "msg-1"}, {ItemIdentifier: "msg-3"}}, response.BatchItemFailures)
+		})
+	})
+}
@@ -0,0 +1,25 @@
+package eventprocessor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nivedita-verma/event-processor/internal/pkg/eventstore"
+	"github.com/nivedita-verma/event-processor/internal/pkg/fieldcrypt"
+)
+
+// EncryptFields encrypts the sensitive fields of the event's data and records
+// the data key and paths in the record, so they are stored and published as
+// ciphertext. Stages that read the data must run before it, so it belongs at
+// the end of the pipeline. Errors from the key provider are left retryable.
+func EncryptFields(encryptor *fieldcrypt.Encryptor) Stage {
+	return func(ctx context.Context, record *eventstore.Record) (Decision, error) {
+		event, envelope, err := encryptor.Encrypt(ctx, record.Event)
+		if err != nil {
+			return Continue, fmt.Errorf("failed to encrypt event %s: %w", record.EventID, err)
+		}
+		record.Event = event
+		record.Encryption = envelope
+		return Continue, nil
+	}
+}
@@ -0,0 +1,76 @@
+package eventprocessor
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/nivedita-verma/event-processor/internal/pkg/eventstore"
+	"github.com/nivedita-verma/event-processor/internal/pkg/fieldcrypt"
+	"github.com/nivedita-verma/event-processor/pkg/eventspec"
+	"github.com/stretchr/testify/assert"
+)
+
+type failingKeyProvider struct {
+	fieldcrypt.KeyProvider
+}
+
+func (failingKeyProvider) GenerateDataKey(context.Context) (fieldcrypt.DataKey, error) {
+	return fieldcrypt.DataKey{}, assert.AnError
+}
+
+func Test_EncryptFields(t *testing.T) {
+	policy := fieldcrypt.Policy{"transaction": {"data.cardNumber"}}
+	provider, err := fieldcrypt.NewLocalKeyProvider("local-key", bytes.Repeat([]byte{1}, 32))
+	assert.NoError(t, err)
+	encryptor, err := fieldcrypt.New(provider, policy)
+	assert.NoError(t, err)
+	event := eventspec.Event{EventID: "1", ClientID: "client-1", Type: "transaction", Data: map[string]interface{}{"amount": 10.0, "cardNumber": "4111111111111111"}}
+
+	t.Run("when the event has sensitive fields", func(t *testing.T) {
+		record := &eventstore.Record{Event: event}
+
+		decision, err := EncryptFields(encryptor)(context.Background(), record)
+
+		t.Run("should encrypt them and continue", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Equal(t, Continue, decision)
+			assert.NotEqual(t, "4111111111111111", record.Data["cardNumber"])
+			assert.Equal(t, 10.0, record.Data["amount"])
+		})
+
+		t.Run("should record the envelope", func(t *testing.T) {
+			assert.Equal(t, "local-key", record.Encryption.KeyID)
+			assert.Equal(t, []string{"data.cardNumber"}, record.Encryption.Paths)
+		})
+
+		t.Run("should be decryptable", func(t *testing.T) {
+			decrypted, err := encryptor.Decrypt(context.Background(), record.Event, record.Encryption)
+			assert.NoError(t, err)
+			assert.Equal(t, event, decrypted)
+		})
+	})
+
+	t.Run("when the event has no sensitive fields", func(t *testing.T) {
+		record := &eventstore.Record{Event: eventspec.Event{EventID: "2", Type: "notification", Data: map[string]interface{}{"message": "hello"}}}
+
+		_, err := EncryptFields(encryptor)(context.Background(), record)
+
+		t.Run("should leave it unencrypted", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Nil(t, record.Encryption)
+			assert.Equal(t, "hello", record.Data["message"])
+		})
+	})
+
+	t.Run("when no data key can be generated", func(t *testing.T) {
+		failing, err := fieldcrypt.New(failingKeyProvider{}, policy)
+		assert.NoError(t, err)
+
+		_, err = EncryptFields(failing)(context.Background(), &eventstore.Record{Event: event})
+
+		t.Run("should return the error", func(t *testing.T) {
+			assert.ErrorIs(t, err, assert.AnError)
+		})
+	})
+}
@@ -0,0 +1,67 @@
+package eventprocessor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/nivedita-verma/event-processor/internal/pkg/failure"
+	"github.com/nivedita-verma/event-processor/pkg/eventspec"
+)
+
+// HandleEventBridgeEvent validates and processes an event delivered by an
+// EventBridge rule. A detail with a data field is taken as the event, and a
+// detail without one as the event's data. Fields the detail leaves out are
+// taken from the EventBridge envelope: eventId from its id, clientId from its
+// source and type from its detail-type. EventBridge events carry no
+// attributes, so they cannot be signed, and the handler must not verify
+// signatures. An error is returned if the event is to be retried, leaving the
+// retry to Lambda's asynchronous invocation.
+func (h *Handler) HandleEventBridgeEvent(ctx context.Context, event events.CloudWatchEvent) error {
+	envelope, err := eventBridgeEnvelope(event)
+	var result Result
+	if err != nil {
+		result = h.reject(ctx, envelope, err)
+	} else {
+		result = h.Ingest(ctx, []Envelope{envelope})[0]
+	}
+	if result.Retry {
+		return fmt.Errorf("failed to process EventBridge event %s", event.ID)
+	}
+	return nil
+}
+
+// eventBridgeEnvelope carries the event of an EventBridge event, with the
+// time of the event as the time it was sent. A detail that is not a JSON
+// object is a permanent failure, returned with an envelope carrying the whole
+// EventBridge event to be quarantined.
+func eventBridgeEnvelope(event events.CloudWatchEvent) (Envelope, error) {
+	envelope := Envelope{
+		MessageID: event.ID,
+		Source:    "aws:events",
+		SentAt:    event.Time,
+	}
+
+	var detail map[string]json.RawMessage
+	if err := json.Unmarshal(event.Detail, &detail); err != nil || detail == nil {
+		raw, _ := json.Marshal(event)
+		envelope.Body = string(raw)
+		return envelope, failure.Permanent(eventspec.ValidationErrors{{Code: eventspec.CodeMalformedJSON, Message: "event detail must be a JSON object"}})
+	}
+	if _, ok := detail["data"]; !ok {
+		detail = map[string]json.RawMessage{"data": event.Detail}
+	}
+	for name, value := range map[string]string{
+		"eventId":  event.ID,
+		"clientId": event.Source,
+		"type":     event.DetailType,
+	} {
+		if _, ok := detail[name]; !ok && value != "" {
+			detail[name], _ = json.Marshal(value)
+		}
+	}
+	body, _ := json.Marshal(detail)
+	envelope.Body = string(body)
+	return envelope, nil
+}
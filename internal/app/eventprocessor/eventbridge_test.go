@@ -0,0 +1,101 @@
+package eventprocessor
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/nivedita-verma/event-processor/internal/pkg/eventstore"
+	"github.com/nivedita-verma/event-processor/pkg/eventspec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func newEventBridgeEvent(detailType, detail string) events.CloudWatchEvent {
+	return events.CloudWatchEvent{
+		ID:         "eb-1",
+		DetailType: detailType,
+		Source:     "client-1",
+		Time:       time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		Detail:     json.RawMessage(detail),
+	}
+}
+
+func Test_Handler_HandleEventBridgeEvent(t *testing.T) {
+	t.Run("when the detail is the event's data", func(t *testing.T) {
+		service := &mockService{}
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t))
+		service.On("Process", mock.Anything, mock.Anything).Return(nil)
+
+		err := handler.HandleEventBridgeEvent(context.Background(), newEventBridgeEvent("notification", `{"message":"hello"}`))
+
+		t.Run("then the event should be taken from the envelope", func(t *testing.T) {
+			assert.NoError(t, err)
+			service.AssertCalled(t, "Process", mock.Anything, withEvent(eventspec.Event{
+				EventID:  "eb-1",
+				ClientID: "client-1",
+				Type:     "notification",
+				Data:     map[string]interface{}{"message": "hello"},
+			}))
+		})
+
+		t.Run("then it should be processed with the event's metadata", func(t *testing.T) {
+			record := service.Calls[0].Arguments.Get(1).(eventstore.Record)
+			assert.Equal(t, "eb-1", record.Metadata.MessageID)
+			assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), record.Metadata.SentAt)
+		})
+	})
+
+	t.Run("when the detail is an event", func(t *testing.T) {
+		service := &mockService{}
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t))
+		service.On("Process", mock.Anything, mock.Anything).Return(nil)
+
+		err := handler.HandleEventBridgeEvent(context.Background(), newEventBridgeEvent("Event Published",
+			`{"eventId":"1","type":"notification","data":{"message":"hello"}}`))
+
+		t.Run("then its fields should be kept and missing fields taken from the envelope", func(t *testing.T) {
+			assert.NoError(t, err)
+			service.AssertCalled(t, "Process", mock.Anything, withEvent(eventspec.Event{
+				EventID:  "1",
+				ClientID: "client-1",
+				Type:     "notification",
+				Data:     map[string]interface{}{"message": "hello"},
+			}))
+		})
+	})
+
+	t.Run("when the event fails with a retryable error", func(t *testing.T) {
+		service := &mockService{}
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t))
+		service.On("Process", mock.Anything, mock.Anything).Return(assert.AnError)
+
+		err := handler.HandleEventBridgeEvent(context.Background(), newEventBridgeEvent("notification", `{"message":"hello"}`))
+
+		t.Run("then an error should be returned for Lambda to retry", func(t *testing.T) {
+			assert.EqualError(t, err, "failed to process EventBridge event eb-1")
+		})
+	})
+
+	t.Run("when the detail is not an object", func(t *testing.T) {
+		service := &mockService{}
+		quarantine := eventstore.NewMemoryQuarantine()
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t), WithQuarantine(quarantine))
+
+		err := handler.HandleEventBridgeEvent(context.Background(), newEventBridgeEvent("notification", `"hello"`))
+
+		t.Run("then the EventBridge event should be quarantined", func(t *testing.T) {
+			assert.NoError(t, err)
+			messages := quarantine.Messages()
+			if assert.Len(t, messages, 1) {
+				assert.Equal(t, "eb-1", messages[0].MessageID)
+				assert.Equal(t, "MALFORMED_JSON", messages[0].RejectionCode)
+				assert.Contains(t, messages[0].Body, `"detail-type":"notification"`)
+			}
+			service.AssertNotCalled(t, "Process", mock.Anything, mock.Anything)
+		})
+	})
+}
@@ -0,0 +1,47 @@
+package eventprocessor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+)
+
+const (
+	// maxFileLineBytes bounds an event of a file, as maxRequestBytes does a
+	// request.
+	maxFileLineBytes = 1 << 20
+	// fileBatchSize is how many events of a file are ingested at once.
+	fileBatchSize = 100
+)
+
+// IngestFile ingests the events of a file holding one JSON event per line,
+// such as events exported for a backfill or replay. Blank lines are skipped.
+// Each event is delivered in an envelope identified by the file name and line
+// number. Results are returned in file order; the error is only set if the
+// file could not be read, after ingesting the events read so far.
+func (h *Handler) IngestFile(ctx context.Context, name string, file io.Reader) ([]Result, error) {
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxFileLineBytes)
+	var results []Result
+	var batch []Envelope
+	for line := 1; scanner.Scan(); line++ {
+		body := bytes.TrimSpace(scanner.Bytes())
+		if len(body) == 0 {
+			continue
+		}
+		batch = append(batch, Envelope{MessageID: fmt.Sprintf("%s:%d", name, line), Body: string(body), Source: "file"})
+		if len(batch) == fileBatchSize {
+			results = append(results, h.Ingest(ctx, batch)...)
+			batch = nil
+		}
+	}
+	if len(batch) > 0 {
+		results = append(results, h.Ingest(ctx, batch)...)
+	}
+	if err := scanner.Err(); err != nil {
+		return results, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return results, nil
+}
@@ -0,0 +1,47 @@
+package eventprocessor
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/nivedita-verma/event-processor/internal/pkg/eventstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func Test_Handler_IngestFile(t *testing.T) {
+	t.Run("when the file holds events and blank lines", func(t *testing.T) {
+		service := &mockService{}
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t))
+		service.On("Process", mock.Anything, mock.Anything).Return(nil)
+		file := strings.Join([]string{notificationBody("1", "client-1"), "", notificationBody("2", "client-1")}, "\n")
+
+		results, err := handler.IngestFile(context.Background(), "events.jsonl", strings.NewReader(file))
+
+		t.Run("then every event should be ingested, identified by line", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Equal(t, []Result{
+				{MessageID: "events.jsonl:1", EventID: "1"},
+				{MessageID: "events.jsonl:3", EventID: "2"},
+			}, results)
+			record := service.Calls[1].Arguments.Get(1).(eventstore.Record)
+			assert.Equal(t, "events.jsonl:3", record.Metadata.MessageID)
+		})
+	})
+
+	t.Run("when a line is too long", func(t *testing.T) {
+		service := &mockService{}
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t))
+		service.On("Process", mock.Anything, mock.Anything).Return(nil)
+		file := notificationBody("1", "client-1") + "\n" + strings.Repeat("x", maxFileLineBytes+1)
+
+		results, err := handler.IngestFile(context.Background(), "events.jsonl", strings.NewReader(file))
+
+		t.Run("then the events before it should be ingested and an error returned", func(t *testing.T) {
+			assert.ErrorContains(t, err, "failed to read events.jsonl")
+			assert.Len(t, results, 1)
+		})
+	})
+}
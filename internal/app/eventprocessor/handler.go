@@ -3,70 +3,339 @@ package eventprocessor
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
-	"github.com/aws/aws-lambda-go/events"
+	"github.com/nivedita-verma/event-processor/internal/pkg/clientregistry"
+	"github.com/nivedita-verma/event-processor/internal/pkg/eventstore"
+	"github.com/nivedita-verma/event-processor/internal/pkg/failure"
+	"github.com/nivedita-verma/event-processor/internal/pkg/redact"
+	"github.com/nivedita-verma/event-processor/internal/pkg/signing"
 	"github.com/nivedita-verma/event-processor/pkg/eventspec"
 	"go.uber.org/zap"
 )
 
+// EnvelopeMode controls how fields not declared by the envelope schema are treated.
+type EnvelopeMode string
+
+const (
+	// EnvelopeModeLenient logs unknown envelope fields and accepts the event.
+	EnvelopeModeLenient EnvelopeMode = "lenient"
+	// EnvelopeModeStrict rejects events carrying unknown envelope fields.
+	EnvelopeModeStrict EnvelopeMode = "strict"
+)
+
 type Handler struct {
-	logger  *zap.SugaredLogger
-	service ServiceApi
+	logger        *zap.SugaredLogger
+	service       ServiceApi
+	schemas       *eventspec.SchemaRegistry
+	envelopeMode  EnvelopeMode
+	quarantine    eventstore.Quarantine
+	clients       clientregistry.Api
+	signingKeys   signing.KeyProvider
+	redactor      *redact.Redactor
+	concurrency   int
+	orderByClient bool
+	batchWrites   bool
+	version       string
+	now           func() time.Time
 }
 
-func NewHandler(logger *zap.SugaredLogger, service ServiceApi) *Handler {
-	return &Handler{
-		logger:  logger,
-		service: service,
+type HandlerOption func(*Handler)
+
+// WithEnvelopeMode sets how unknown envelope fields are treated. Defaults to EnvelopeModeLenient.
+func WithEnvelopeMode(mode EnvelopeMode) HandlerOption {
+	return func(h *Handler) {
+		h.envelopeMode = mode
 	}
 }
 
-type ServiceApi interface {
-	Process(context.Context, eventspec.Event) error
+// WithQuarantine sets where permanently failing messages are recorded. Defaults
+// to logging the message, redacted.
+func WithQuarantine(quarantine eventstore.Quarantine) HandlerOption {
+	return func(h *Handler) {
+		h.quarantine = quarantine
+	}
+}
+
+// WithClientChecks rejects events of clients that are not in the registry, are
+// suspended, or may not send events of the event's type. By default events are
+// accepted for any client.
+func WithClientChecks(registry clientregistry.Api) HandlerOption {
+	return func(h *Handler) {
+		h.clients = registry
+	}
+}
+
+// WithSignatureVerification rejects events whose message does not carry a
+// valid signature, made with the key of the event's client, in its
+// eventspec.SignatureAttribute and eventspec.SignatureAlgorithmAttribute
+// attributes. By default signatures are not checked.
+func WithSignatureVerification(keys signing.KeyProvider) HandlerOption {
+	return func(h *Handler) {
+		h.signingKeys = keys
+	}
+}
+
+// WithRedactor sets how sensitive data is masked in logged messages and
+// rejections. Defaults to redact.DefaultPolicy.
+func WithRedactor(redactor *redact.Redactor) HandlerOption {
+	return func(h *Handler) {
+		h.redactor = redactor
+	}
+}
+
+// WithConcurrency sets how many messages of a batch are processed in parallel.
+// Defaults to 1, i.e. sequential processing.
+func WithConcurrency(concurrency int) HandlerOption {
+	return func(h *Handler) {
+		h.concurrency = max(concurrency, 1)
+	}
+}
+
+// WithClientOrdering processes messages for the same client one at a time, in
+// batch order, so their relative order is kept when processing concurrently.
+func WithClientOrdering() HandlerOption {
+	return func(h *Handler) {
+		h.orderByClient = true
+	}
+}
+
+// WithBatchWrites processes batches of more than one message with a single
+// call to the service's ProcessBatch, when the service supports it. Messages
+// are then not processed concurrently or ordered by client.
+func WithBatchWrites() HandlerOption {
+	return func(h *Handler) {
+		h.batchWrites = true
+	}
 }
 
-func (h *Handler) HandleSQSEvent(ctx context.Context, sqsEvent events.SQSEvent) (events.SQSEventResponse, error) {
-	sqsEventResponse := events.SQSEventResponse{
-		BatchItemFailures: []events.SQSBatchItemFailure{},
+// WithProcessorVersion sets the processor version recorded in the metadata of
+// every persisted event.
+func WithProcessorVersion(version string) HandlerOption {
+	return func(h *Handler) {
+		h.version = version
+	}
+}
+
+func NewHandler(logger *zap.SugaredLogger, service ServiceApi, schemas *eventspec.SchemaRegistry, opts ...HandlerOption) *Handler {
+	handler := &Handler{
+		logger:       logger,
+		service:      service,
+		schemas:      schemas,
+		envelopeMode: EnvelopeModeLenient,
+		redactor:     redact.MustNew(redact.DefaultPolicy()),
+		concurrency:  1,
+		now:          time.Now,
+	}
+	for _, opt := range opts {
+		opt(handler)
+	}
+	if handler.quarantine == nil {
+		handler.quarantine = &logQuarantine{logger: logger, redactor: handler.redactor}
 	}
-	for _, message := range sqsEvent.Records {
-		h.logger.Infof("Received message ID: %s, from source %s", message.MessageId, message.EventSource)
-		event, err := h.validateSQSMessage(message)
+	return handler
+}
+
+// ServiceApi processes validated events. Errors that will not succeed on retry
+// are marked with failure.Permanent; all other errors are retried.
+type ServiceApi interface {
+	Process(context.Context, eventstore.Record) error
+}
+
+// BatchServiceApi is implemented by services that can process several events
+// at once. Per-event failures are returned as a *eventstore.BatchError.
+type BatchServiceApi interface {
+	ProcessBatch(context.Context, []eventstore.Record) error
+}
+
+// logQuarantine records quarantined messages in the logs only. It is used when
+// no quarantine store is configured.
+type logQuarantine struct {
+	logger   *zap.SugaredLogger
+	redactor *redact.Redactor
+}
+
+func (q *logQuarantine) Quarantine(_ context.Context, message eventstore.QuarantinedMessage) error {
+	q.logger.Errorw("Quarantined message", "messageId", message.MessageID, "code", message.RejectionCode, "body", q.redactor.Body(message.Body), "error", message.Reason)
+	return nil
+}
+
+// processBatch validates every envelope, unless events holds their events
+// already, and hands the valid events to the service in one call, mapping
+// per-event failures back to their envelopes.
+func (h *Handler) processBatch(ctx context.Context, batchService BatchServiceApi, envelopes []Envelope, events []*eventspec.Event) []Result {
+	results := make([]Result, len(envelopes))
+	var batch []eventstore.Record
+	var envelopeIndexes []int
+	for i, envelope := range envelopes {
+		h.logger.Infof("Received message ID: %s, from source %s", envelope.MessageID, envelope.Source)
+		receivedAt := h.now()
+		event, err := h.eventOf(ctx, envelopes, events, i)
 		if err != nil {
-			h.logger.Errorf("failed to validate SQS message: %v", err)
-			sqsEventResponse.BatchItemFailures = append(sqsEventResponse.BatchItemFailures, events.SQSBatchItemFailure{ItemIdentifier: message.MessageId})
+			results[i] = h.reject(ctx, envelope, err)
 			continue
 		}
+		results[i] = Result{MessageID: envelope.MessageID, EventID: event.EventID}
+		batch = append(batch, h.newRecord(envelope, *event, receivedAt))
+		envelopeIndexes = append(envelopeIndexes, i)
+	}
+	if len(batch) == 0 {
+		return results
+	}
 
-		if err := h.service.Process(ctx, *event); err != nil {
-			h.logger.Errorf("failed to process event ID %s: %v", event.EventID, err)
-			sqsEventResponse.BatchItemFailures = append(sqsEventResponse.BatchItemFailures, events.SQSBatchItemFailure{ItemIdentifier: message.MessageId})
-			continue
+	err := batchService.ProcessBatch(ctx, batch)
+	if err == nil {
+		return results
+	}
+	var batchErr *eventstore.BatchError
+	if !errors.As(err, &batchErr) {
+		// The whole batch failed, so every event shares the error
+		batchErr = &eventstore.BatchError{Errors: make(map[int]error, len(batch))}
+		for j := range batch {
+			batchErr.Errors[j] = err
 		}
 	}
+	for j, itemErr := range batchErr.Errors {
+		i := envelopeIndexes[j]
+		h.logger.Errorf("failed to process event ID %s: %v", batch[j].EventID, itemErr)
+		results[i] = h.result(ctx, envelopes[i], batch[j].EventID, itemErr)
+	}
+	return results
+}
 
-	return sqsEventResponse, nil
+// newRecord pairs a validated event with the metadata of the envelope it was
+// delivered in.
+func (h *Handler) newRecord(envelope Envelope, event eventspec.Event, receivedAt time.Time) eventstore.Record {
+	metadata := eventstore.Metadata{
+		MessageID:        envelope.MessageID,
+		ReceivedAt:       receivedAt.UTC(),
+		ReceiveCount:     envelope.ReceiveCount,
+		ProcessorVersion: h.version,
+	}
+	if !envelope.SentAt.IsZero() {
+		metadata.SentAt = envelope.SentAt.UTC()
+	}
+	return eventstore.Record{Event: event, Metadata: metadata}
 }
 
-func (h *Handler) validateSQSMessage(message events.SQSMessage) (*eventspec.Event, error) {
-	h.logger.Infof("Validating message ID: %s", message.MessageId)
-	if message.Body == "" {
-		return nil, fmt.Errorf("empty message body")
+// rejectionCodeProcessingFailed is recorded for permanent failures that are
+// not validation errors.
+const rejectionCodeProcessingFailed = "PROCESSING_FAILED"
+
+// newQuarantinedMessage records why the envelope was rejected. The body and
+// validation errors, which may quote sensitive values, are redacted, as the
+// quarantine is not encrypted field by field as stored events are.
+func (h *Handler) newQuarantinedMessage(envelope Envelope, reason error) eventstore.QuarantinedMessage {
+	quarantined := eventstore.QuarantinedMessage{
+		MessageID:     envelope.MessageID,
+		Body:          h.redactor.Body(envelope.Body),
+		RejectionCode: rejectionCodeProcessingFailed,
+		Reason:        reason.Error(),
+		ReceiveCount:  envelope.ReceiveCount,
+		QuarantinedAt: h.now().UTC(),
+	}
+	if errs := eventspec.AsValidationErrors(reason); len(errs) > 0 {
+		quarantined.Reason = h.redactor.ValidationErrors(errs).Error()
+		quarantined.RejectionCode = string(errs[0].Code)
+		for _, validationErr := range errs {
+			quarantined.RejectionCodes = append(quarantined.RejectionCodes, string(validationErr.Code))
+		}
+	}
+
+	// Identify the event where possible so rejections can be looked up by client
+	event := eventspec.Event{}
+	if err := json.Unmarshal([]byte(envelope.Body), &event); err == nil {
+		quarantined.ClientID = event.ClientID
+		quarantined.EventID = event.EventID
+	}
+	return quarantined
+}
+
+// validate decodes and validates the envelope body, collecting every violation
+// found rather than stopping at the first. Validation failures are returned as
+// permanent eventspec.ValidationErrors; failing to read the client registry or
+// a signing key is retryable.
+func (h *Handler) validate(ctx context.Context, envelope Envelope) (*eventspec.Event, error) {
+	h.logger.Infof("Validating message ID: %s", envelope.MessageID)
+	if envelope.Body == "" {
+		return nil, failure.Permanent(eventspec.ValidationErrors{{Code: eventspec.CodeEmptyBody, Message: "empty message body"}})
+	}
+
+	errs := eventspec.AsValidationErrors(h.schemas.ValidateEnvelope([]byte(envelope.Body)))
+	if unknownFields := errs.WithCode(eventspec.CodeUnknownField); len(unknownFields) > 0 && h.envelopeMode != EnvelopeModeStrict {
+		h.logger.Warnf("Message ID %s contains unknown event fields: %v", envelope.MessageID, unknownFields)
+		errs = errs.WithoutCode(eventspec.CodeUnknownField)
+	}
+	if len(errs) > 0 {
+		return nil, failure.Permanent(errs)
 	}
 
 	event := &eventspec.Event{}
-	if err := json.Unmarshal([]byte(message.Body), event); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal message body: %w", err)
+	if err := json.Unmarshal([]byte(envelope.Body), event); err != nil {
+		return nil, failure.Permanent(eventspec.ValidationErrors{{Code: eventspec.CodeMalformedJSON, Message: fmt.Sprintf("failed to unmarshal message body: %v", err)}})
+	}
+
+	requiredFields := []struct {
+		pointer string
+		value   string
+	}{
+		{"/eventId", event.EventID},
+		{"/clientId", event.ClientID},
+		{"/type", event.Type},
+	}
+	for _, field := range requiredFields {
+		if field.value == "" {
+			errs = append(errs, eventspec.ValidationError{Code: eventspec.CodeMissingField, Pointer: field.pointer, Message: "must not be empty"})
+		}
+	}
+
+	if event.Type != "" && !eventspec.IsValidEventType(event.Type) {
+		errs = append(errs, eventspec.ValidationError{Code: eventspec.CodeUnsupportedType, Pointer: "/type", Message: fmt.Sprintf("unsupported event type: %s", event.Type)})
 	}
 
-	if event.EventID == "" || event.ClientID == "" || event.Type == "" || event.Data == nil {
-		return nil, fmt.Errorf("missing required event fields")
+	if eventspec.IsValidEventType(event.Type) {
+		errs = append(errs, eventspec.AsValidationErrors(h.schemas.Validate(*event))...)
 	}
 
-	if !eventspec.IsValidEventType(event.Type) {
-		return nil, fmt.Errorf("unsupported event type: %s", event.Type)
+	if h.clients != nil && event.ClientID != "" {
+		clientErrs, err := checkClient(ctx, h.clients, *event)
+		if err != nil {
+			return nil, err
+		}
+		errs = append(errs, clientErrs...)
 	}
 
+	if h.signingKeys != nil && event.ClientID != "" {
+		signatureErrs, err := checkSignature(ctx, h.signingKeys, envelope, event.ClientID)
+		if err != nil {
+			return nil, err
+		}
+		errs = append(errs, signatureErrs...)
+	}
+
+	if len(errs) > 0 {
+		return nil, failure.Permanent(errs)
+	}
 	return event, nil
 }
+
+// logRejection logs each validation error of a rejected message as its own
+// structured entry so that rejections can be grouped by code. Every ingress
+// must report rejected messages through here to keep the log shape consistent.
+func (h *Handler) logRejection(messageID string, err error) {
+	errs := h.redactor.ValidationErrors(eventspec.AsValidationErrors(err))
+	if errs == nil {
+		h.logger.Errorw("Rejected message", "messageId", messageID, "error", err.Error())
+		return
+	}
+	for _, validationErr := range errs {
+		h.logger.Errorw("Rejected message",
+			"messageId", messageID,
+			"code", string(validationErr.Code),
+			"pointer", validationErr.Pointer,
+			"error", validationErr.Message,
+		)
+	}
+}
@@ -2,74 +2,212 @@ package eventprocessor
 
 import (
 	"context"
+	"crypto/ed25519"
 	"fmt"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
+	"github.com/nivedita-verma/event-processor/internal/pkg/clientregistry"
+	"github.com/nivedita-verma/event-processor/internal/pkg/eventstore"
+	"github.com/nivedita-verma/event-processor/internal/pkg/failure"
+	"github.com/nivedita-verma/event-processor/internal/pkg/signing"
 	"github.com/nivedita-verma/event-processor/pkg/eventspec"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func Test_NewHandler(t *testing.T) {
 	store := &mockStore{}
 	service := NewService(store)
-	handler := NewHandler(zap.NewNop().Sugar(), service)
+	handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t))
 	assert.Equal(t, service, handler.service)
 	assert.NotNil(t, handler.logger)
+	assert.NotNil(t, handler.schemas)
+	assert.Equal(t, EnvelopeModeLenient, handler.envelopeMode)
 }
 
-func Test_Handler_validateSQSMessage(t *testing.T) {
-	t.Run("when SQS message body is empty", func(t *testing.T) {
+func Test_NewHandler_WithEnvelopeMode(t *testing.T) {
+	handler := NewHandler(zap.NewNop().Sugar(), &mockService{}, newSchemaRegistry(t), WithEnvelopeMode(EnvelopeModeStrict))
+	assert.Equal(t, EnvelopeModeStrict, handler.envelopeMode)
+}
+
+func newSchemaRegistry(t *testing.T) *eventspec.SchemaRegistry {
+	t.Helper()
+	schemas, err := eventspec.NewSchemaRegistry()
+	if err != nil {
+		t.Fatalf("failed to load schema registry: %v", err)
+	}
+	return schemas
+}
+
+func Test_Handler_validate(t *testing.T) {
+	t.Run("when the message body is empty", func(t *testing.T) {
 		service := &mockService{}
-		handler := NewHandler(zap.NewNop().Sugar(), service)
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t))
 
-		_, err := handler.validateSQSMessage(events.SQSMessage{Body: ""})
+		_, err := handler.validate(context.Background(), Envelope{Body: ""})
 
 		t.Run("should return validation error", func(t *testing.T) {
-			assert.Error(t, err)
-			assert.Equal(t, "empty message body", err.Error())
+			assert.Equal(t, eventspec.ValidationErrors{{Code: eventspec.CodeEmptyBody, Message: "empty message body"}}, eventspec.AsValidationErrors(err))
 		})
 	})
 
-	t.Run("when SQS message body is valid JSON", func(t *testing.T) {
+	t.Run("when the message is invalid", func(t *testing.T) {
+		handler := NewHandler(zap.NewNop().Sugar(), &mockService{}, newSchemaRegistry(t))
+
+		_, err := handler.validate(context.Background(), Envelope{MessageID: "msg-1", Body: `{"eventId":"1"}`})
+
+		t.Run("should return a permanent error", func(t *testing.T) {
+			assert.True(t, failure.IsPermanent(err))
+		})
+	})
+
+	t.Run("when the message body is valid JSON", func(t *testing.T) {
 		service := &mockService{}
-		handler := NewHandler(zap.NewNop().Sugar(), service)
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t))
 
-		event, err := handler.validateSQSMessage(events.SQSMessage{Body: `{"eventId":"1","clientId":"client-1","type":"notification","data":{"key":"value"}}`, MessageId: "msg-1"})
+		event, err := handler.validate(context.Background(), Envelope{MessageID: "msg-1", Body: `{"eventId":"1","clientId":"client-1","type":"notification","data":{"message":"hello"}}`})
 
 		t.Run("should complete without error", func(t *testing.T) {
 			assert.NoError(t, err)
 		})
 
 		t.Run("should return the correct event", func(t *testing.T) {
-			expectedEvent := &eventspec.Event{EventID: "1", ClientID: "client-1", Type: "notification", Data: map[string]interface{}{"key": "value"}}
+			expectedEvent := &eventspec.Event{EventID: "1", ClientID: "client-1", Type: "notification", Data: map[string]interface{}{"message": "hello"}}
 			assert.Equal(t, expectedEvent, event)
 		})
 	})
 
-	t.Run("when SQS message body is invalid JSON", func(t *testing.T) {
+	t.Run("when the message body is invalid JSON", func(t *testing.T) {
 		service := &mockService{}
-		handler := NewHandler(zap.NewNop().Sugar(), service)
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t))
 
-		_, err := handler.validateSQSMessage(events.SQSMessage{Body: `{"eventId":"1","clientId":"client-1","type":"monitoringAlert","data":{"key":"value"`, MessageId: "msg-1"})
+		_, err := handler.validate(context.Background(), Envelope{MessageID: "msg-1", Body: `{"eventId":"1","clientId":"client-1","type":"monitoringAlert","data":{"key":"value"`})
 
-		t.Run("should return unmarshalling error", func(t *testing.T) {
-			assert.Error(t, err)
-			assert.Contains(t, err.Error(), "failed to unmarshal message body")
+		t.Run("should return malformed JSON error", func(t *testing.T) {
+			errs := eventspec.AsValidationErrors(err)
+			if assert.Len(t, errs, 1) {
+				assert.Equal(t, eventspec.CodeMalformedJSON, errs[0].Code)
+			}
 		})
 	})
 
-	t.Run("when SQS message has unsupported event type", func(t *testing.T) {
+	t.Run("when the message body does not match the envelope schema", func(t *testing.T) {
 		service := &mockService{}
-		handler := NewHandler(zap.NewNop().Sugar(), service)
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t))
 
-		_, err := handler.validateSQSMessage(events.SQSMessage{Body: `{"eventId":"1","clientId":"client-1","type":"UnsupportedEvent","data":{"key":"value"}}`, MessageId: "msg-1"})
+		_, err := handler.validate(context.Background(), Envelope{MessageID: "msg-1", Body: `{"eventId":"1","type":"notification","data":{"message":"hello"}}`})
+
+		t.Run("should return envelope validation error", func(t *testing.T) {
+			assert.Equal(t, eventspec.ValidationErrors{{Code: eventspec.CodeMissingField, Pointer: "/clientId", Message: "missing required property"}}, eventspec.AsValidationErrors(err))
+		})
+	})
+
+	t.Run("when the message body has unknown fields in lenient mode", func(t *testing.T) {
+		service := &mockService{}
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t), WithEnvelopeMode(EnvelopeModeLenient))
+
+		event, err := handler.validate(context.Background(), Envelope{MessageID: "msg-1", Body: `{"eventId":"1","clientId":"client-1","clientID":"client-1","type":"notification","data":{"message":"hello"}}`})
+
+		t.Run("should complete without error", func(t *testing.T) {
+			assert.NoError(t, err)
+		})
+
+		t.Run("should return the event without the unknown fields", func(t *testing.T) {
+			expectedEvent := &eventspec.Event{EventID: "1", ClientID: "client-1", Type: "notification", Data: map[string]interface{}{"message": "hello"}}
+			assert.Equal(t, expectedEvent, event)
+		})
+	})
+
+	t.Run("when the message body has unknown fields in strict mode", func(t *testing.T) {
+		service := &mockService{}
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t), WithEnvelopeMode(EnvelopeModeStrict))
+
+		_, err := handler.validate(context.Background(), Envelope{MessageID: "msg-1", Body: `{"eventId":"1","clientId":"client-1","clientID":"client-1","type":"notification","data":{"message":"hello"}}`})
+
+		t.Run("should return unknown field error", func(t *testing.T) {
+			assert.Equal(t, eventspec.ValidationErrors{{Code: eventspec.CodeUnknownField, Pointer: "/clientID", Message: "property is not declared by the schema"}}, eventspec.AsValidationErrors(err))
+		})
+	})
+
+	t.Run("when the message data does not match the schema for its type", func(t *testing.T) {
+		service := &mockService{}
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t))
+
+		_, err := handler.validate(context.Background(), Envelope{MessageID: "msg-1", Body: `{"eventId":"1","clientId":"client-1","type":"transaction","data":{"currency":"GBP"}}`})
+
+		t.Run("should return data validation error", func(t *testing.T) {
+			assert.Equal(t, eventspec.ValidationErrors{{Code: eventspec.CodeMissingField, Pointer: "/data/amount", Message: "missing required property"}}, eventspec.AsValidationErrors(err))
+		})
+	})
+
+	t.Run("when the message has several problems", func(t *testing.T) {
+		service := &mockService{}
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t))
+
+		_, err := handler.validate(context.Background(), Envelope{MessageID: "msg-1", Body: `{"eventId":"","clientId":"","type":"transaction","data":{}}`})
+
+		t.Run("should return all of them", func(t *testing.T) {
+			assert.ElementsMatch(t, eventspec.ValidationErrors{
+				{Code: eventspec.CodeMissingField, Pointer: "/eventId", Message: "must not be empty"},
+				{Code: eventspec.CodeMissingField, Pointer: "/clientId", Message: "must not be empty"},
+				{Code: eventspec.CodeMissingField, Pointer: "/data/amount", Message: "missing required property"},
+				{Code: eventspec.CodeMissingField, Pointer: "/data/currency", Message: "missing required property"},
+			}, eventspec.AsValidationErrors(err))
+		})
+	})
+
+	t.Run("when the message has unsupported event type", func(t *testing.T) {
+		service := &mockService{}
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t))
+
+		_, err := handler.validate(context.Background(), Envelope{MessageID: "msg-1", Body: `{"eventId":"1","clientId":"client-1","type":"UnsupportedEvent","data":{"key":"value"}}`})
 
 		t.Run("should return unsupported event type error", func(t *testing.T) {
-			assert.Error(t, err)
-			assert.Equal(t, "unsupported event type: UnsupportedEvent", err.Error())
+			assert.Equal(t, eventspec.ValidationErrors{{Code: eventspec.CodeUnsupportedType, Pointer: "/type", Message: "unsupported event type: UnsupportedEvent"}}, eventspec.AsValidationErrors(err))
+		})
+	})
+}
+
+func Test_Handler_logRejection(t *testing.T) {
+	t.Run("when error holds validation errors", func(t *testing.T) {
+		core, logs := observer.New(zap.InfoLevel)
+		handler := NewHandler(zap.New(core).Sugar(), &mockService{}, newSchemaRegistry(t))
+
+		handler.logRejection("msg-1", eventspec.ValidationErrors{
+			{Code: eventspec.CodeMissingField, Pointer: "/eventId", Message: "must not be empty"},
+			{Code: eventspec.CodeUnsupportedType, Pointer: "/type", Message: "unsupported event type: x"},
+		})
+
+		t.Run("should log one structured entry per error", func(t *testing.T) {
+			entries := logs.All()
+			if assert.Len(t, entries, 2) {
+				assert.Equal(t, map[string]interface{}{
+					"messageId": "msg-1",
+					"code":      "MISSING_FIELD",
+					"pointer":   "/eventId",
+					"error":     "must not be empty",
+				}, entries[0].ContextMap())
+				assert.Equal(t, "UNSUPPORTED_TYPE", entries[1].ContextMap()["code"])
+			}
+		})
+	})
+
+	t.Run("when error is not a validation error", func(t *testing.T) {
+		core, logs := observer.New(zap.InfoLevel)
+		handler := NewHandler(zap.New(core).Sugar(), &mockService{}, newSchemaRegistry(t))
+
+		handler.logRejection("msg-1", assert.AnError)
+
+		t.Run("should log a single entry without code", func(t *testing.T) {
+			entries := logs.All()
+			if assert.Len(t, entries, 1) {
+				assert.NotContains(t, entries[0].ContextMap(), "code")
+			}
 		})
 	})
 }
@@ -78,14 +216,45 @@ type mockService struct {
 	mock.Mock
 }
 
-func (m *mockService) Process(ctx context.Context, event eventspec.Event) error {
-	args := m.Called(ctx, event)
+func (m *mockService) Process(ctx context.Context, record eventstore.Record) error {
+	args := m.Called(ctx, record)
 	if args.Get(0) == nil {
 		return nil
 	}
 	return args.Error(0)
 }
 
+// withEvent matches a record carrying the given event, whatever its metadata.
+func withEvent(event eventspec.Event) interface{} {
+	return mock.MatchedBy(func(record eventstore.Record) bool {
+		return reflect.DeepEqual(event, record.Event)
+	})
+}
+
+// withEvents matches records carrying the given events in order.
+func withEvents(events ...eventspec.Event) interface{} {
+	return mock.MatchedBy(func(records []eventstore.Record) bool {
+		if len(records) != len(events) {
+			return false
+		}
+		for i, record := range records {
+			if !reflect.DeepEqual(events[i], record.Event) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+type mockQuarantine struct {
+	mock.Mock
+}
+
+func (m *mockQuarantine) Quarantine(ctx context.Context, message eventstore.QuarantinedMessage) error {
+	args := m.Called(ctx, message)
+	return args.Error(0)
+}
+
 func createSQSEvent(bodies []string) events.SQSEvent {
 	records := make([]events.SQSMessage, len(bodies))
 	for i, body := range bodies {
@@ -103,10 +272,44 @@ func createSQSEvent(bodies []string) events.SQSEvent {
 func Test_Handler_HandleSQSEvent(t *testing.T) {
 	t.Run("when error validating SQS message", func(t *testing.T) {
 		service := &mockService{}
-		handler := NewHandler(zap.NewNop().Sugar(), service)
+		quarantine := eventstore.NewMemoryQuarantine()
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t), WithQuarantine(quarantine))
+		now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		handler.now = func() time.Time { return now }
 
 		// Empty body to trigger validation error
 		sqsEvent := createSQSEvent([]string{""})
+		sqsEvent.Records[0].Attributes = map[string]string{"ApproximateReceiveCount": "1"}
+
+		response, err := handler.HandleSQSEvent(context.Background(), sqsEvent)
+
+		t.Run("then there should be no error", func(t *testing.T) {
+			assert.NoError(t, err)
+		})
+
+		t.Run("then the message should be quarantined with the rejection reason", func(t *testing.T) {
+			messages := quarantine.Messages()
+			if assert.Len(t, messages, 1) {
+				assert.Equal(t, "msg-1", messages[0].MessageID)
+				assert.Equal(t, 1, messages[0].ReceiveCount)
+				assert.Equal(t, "EMPTY_BODY", messages[0].RejectionCode)
+				assert.Equal(t, "EMPTY_BODY: empty message body", messages[0].Reason)
+				assert.Equal(t, now, messages[0].QuarantinedAt)
+			}
+		})
+
+		t.Run("then the response should have no batch item failures", func(t *testing.T) {
+			assert.Empty(t, response.BatchItemFailures)
+		})
+	})
+
+	t.Run("when error validating SQS message and quarantine fails", func(t *testing.T) {
+		service := &mockService{}
+		quarantine := &mockQuarantine{}
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t), WithQuarantine(quarantine))
+
+		sqsEvent := createSQSEvent([]string{""})
+		quarantine.On("Quarantine", mock.Anything, mock.Anything).Return(assert.AnError)
 
 		response, err := handler.HandleSQSEvent(context.Background(), sqsEvent)
 
@@ -120,15 +323,49 @@ func Test_Handler_HandleSQSEvent(t *testing.T) {
 		})
 	})
 
-	t.Run("when service.Process returns an error", func(t *testing.T) {
+	t.Run("when service.Process returns a permanent error", func(t *testing.T) {
+		service := &mockService{}
+		quarantine := eventstore.NewMemoryQuarantine()
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t), WithQuarantine(quarantine))
+
+		validBody := `{"eventId":"1","clientId":"client-1","type":"notification","data":{"message":"hello"}}`
+		sqsEvent := createSQSEvent([]string{validBody})
+
+		event := eventspec.Event{EventID: "1", ClientID: "client-1", Type: "notification", Data: map[string]interface{}{"message": "hello"}}
+		service.On("Process", mock.Anything, withEvent(event)).Return(failure.Permanent(assert.AnError))
+
+		response, err := handler.HandleSQSEvent(context.Background(), sqsEvent)
+
+		t.Run("then there should be no error", func(t *testing.T) {
+			assert.NoError(t, err)
+		})
+
+		t.Run("then the message should be quarantined with the error", func(t *testing.T) {
+			messages := quarantine.Messages()
+			if assert.Len(t, messages, 1) {
+				assert.Equal(t, "client-1", messages[0].ClientID)
+				assert.Equal(t, "1", messages[0].EventID)
+				assert.JSONEq(t, validBody, messages[0].Body)
+				assert.Equal(t, "PROCESSING_FAILED", messages[0].RejectionCode)
+				assert.Equal(t, assert.AnError.Error(), messages[0].Reason)
+			}
+		})
+
+		t.Run("then the response should have no batch item failures", func(t *testing.T) {
+			assert.Empty(t, response.BatchItemFailures)
+		})
+	})
+
+	t.Run("when service.Process returns a retryable error", func(t *testing.T) {
 		service := &mockService{}
-		handler := NewHandler(zap.NewNop().Sugar(), service)
+		quarantine := eventstore.NewMemoryQuarantine()
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t), WithQuarantine(quarantine))
 
-		validBody := `{"eventId":"1","clientId":"client-1","type":"notification","data":{"key":"value"}}`
+		validBody := `{"eventId":"1","clientId":"client-1","type":"notification","data":{"message":"hello"}}`
 		sqsEvent := createSQSEvent([]string{validBody})
 
-		event := eventspec.Event{EventID: "1", ClientID: "client-1", Type: "notification", Data: map[string]interface{}{"key": "value"}}
-		service.On("Process", mock.Anything, event).Return(assert.AnError)
+		event := eventspec.Event{EventID: "1", ClientID: "client-1", Type: "notification", Data: map[string]interface{}{"message": "hello"}}
+		service.On("Process", mock.Anything, withEvent(event)).Return(assert.AnError)
 
 		response, err := handler.HandleSQSEvent(context.Background(), sqsEvent)
 
@@ -136,6 +373,10 @@ func Test_Handler_HandleSQSEvent(t *testing.T) {
 			assert.NoError(t, err)
 		})
 
+		t.Run("then the message should not be quarantined", func(t *testing.T) {
+			assert.Empty(t, quarantine.Messages())
+		})
+
 		t.Run("then the response should include message in batch item failures", func(t *testing.T) {
 			assert.NotEmpty(t, response.BatchItemFailures)
 			assert.Equal(t, "msg-1", response.BatchItemFailures[0].ItemIdentifier)
@@ -144,13 +385,13 @@ func Test_Handler_HandleSQSEvent(t *testing.T) {
 
 	t.Run("when service.Process is successful", func(t *testing.T) {
 		service := &mockService{}
-		handler := NewHandler(zap.NewNop().Sugar(), service)
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t))
 
-		validBody := `{"eventId":"1","clientId":"client-1","type":"transaction","data":{"key":"value"}}`
+		validBody := `{"eventId":"1","clientId":"client-1","type":"transaction","data":{"amount":10,"currency":"GBP"}}`
 		sqsEvent := createSQSEvent([]string{validBody})
 
-		event := eventspec.Event{EventID: "1", ClientID: "client-1", Type: "transaction", Data: map[string]interface{}{"key": "value"}}
-		service.On("Process", mock.Anything, event).Return(nil)
+		event := eventspec.Event{EventID: "1", ClientID: "client-1", Type: "transaction", Data: map[string]interface{}{"amount": float64(10), "currency": "GBP"}}
+		service.On("Process", mock.Anything, withEvent(event)).Return(nil)
 
 		response, err := handler.HandleSQSEvent(context.Background(), sqsEvent)
 
@@ -167,18 +408,19 @@ func Test_Handler_HandleSQSEvent(t *testing.T) {
 
 	t.Run("when multiple SQS messages with mixed results", func(t *testing.T) {
 		service := &mockService{}
-		handler := NewHandler(zap.NewNop().Sugar(), service)
+		quarantine := eventstore.NewMemoryQuarantine()
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t), WithQuarantine(quarantine))
 
-		validBody1 := `{"eventId":"1","clientId":"client-1","type":"notification","data":{"key":"value"}}`
+		validBody1 := `{"eventId":"1","clientId":"client-1","type":"notification","data":{"message":"hello"}}`
 		invalidBody := `{"eventId":"2","clientId":"client-2","type":"unsupported","data":{"key":"value"}}`
-		validBody2 := `{"eventId":"3","clientId":"client-3","type":"transaction","data":{"key":"value"}}`
+		validBody2 := `{"eventId":"3","clientId":"client-3","type":"transaction","data":{"amount":10,"currency":"GBP"}}`
 		sqsEvent := createSQSEvent([]string{validBody1, invalidBody, validBody2})
 
-		event1 := eventspec.Event{EventID: "1", ClientID: "client-1", Type: "notification", Data: map[string]interface{}{"key": "value"}}
-		event2 := eventspec.Event{EventID: "3", ClientID: "client-3", Type: "transaction", Data: map[string]interface{}{"key": "value"}}
+		event1 := eventspec.Event{EventID: "1", ClientID: "client-1", Type: "notification", Data: map[string]interface{}{"message": "hello"}}
+		event2 := eventspec.Event{EventID: "3", ClientID: "client-3", Type: "transaction", Data: map[string]interface{}{"amount": float64(10), "currency": "GBP"}}
 
-		service.On("Process", mock.Anything, event1).Return(nil)
-		service.On("Process", mock.Anything, event2).Return(nil)
+		service.On("Process", mock.Anything, withEvent(event1)).Return(nil)
+		service.On("Process", mock.Anything, withEvent(event2)).Return(assert.AnError)
 
 		response, err := handler.HandleSQSEvent(context.Background(), sqsEvent)
 
@@ -186,11 +428,290 @@ func Test_Handler_HandleSQSEvent(t *testing.T) {
 			assert.NoError(t, err)
 		})
 
-		t.Run("then the response should include only the invalid messages in batch item failures", func(t *testing.T) {
+		t.Run("then only the invalid message should be quarantined", func(t *testing.T) {
+			messages := quarantine.Messages()
+			if assert.Len(t, messages, 1) {
+				assert.Equal(t, "msg-2", messages[0].MessageID)
+				assert.Equal(t, "UNSUPPORTED_TYPE", messages[0].RejectionCode)
+			}
+		})
+
+		t.Run("then the response should include only the retryable messages in batch item failures", func(t *testing.T) {
 			assert.Len(t, response.BatchItemFailures, 1)
-			assert.Equal(t, "msg-2", response.BatchItemFailures[0].ItemIdentifier)
+			assert.Equal(t, "msg-3", response.BatchItemFailures[0].ItemIdentifier)
 		})
 
 		service.AssertExpectations(t)
 	})
 }
+
+type mockBatchService struct {
+	mockService
+}
+
+func (m *mockBatchService) ProcessBatch(ctx context.Context, records []eventstore.Record) error {
+	args := m.Called(ctx, records)
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Error(0)
+}
+
+func Test_Handler_HandleSQSEvent_WithClientChecks(t *testing.T) {
+	registry := clientregistry.NewMemoryRegistry(
+		clientregistry.Client{ClientID: "client-1", Active: true, AllowedEventTypes: []string{"notification"}},
+	)
+
+	t.Run("when the client may send the event", func(t *testing.T) {
+		service := &mockService{}
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t), WithClientChecks(registry))
+		service.On("Process", mock.Anything, mock.Anything).Return(nil)
+
+		response, err := handler.HandleSQSEvent(context.Background(), createSQSEvent([]string{`{"eventId":"1","clientId":"client-1","type":"notification","data":{"message":"hello"}}`}))
+
+		t.Run("then the event should be processed", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Empty(t, response.BatchItemFailures)
+			service.AssertNumberOfCalls(t, "Process", 1)
+		})
+	})
+
+	t.Run("when the client is unknown", func(t *testing.T) {
+		service := &mockService{}
+		quarantine := eventstore.NewMemoryQuarantine()
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t), WithQuarantine(quarantine), WithClientChecks(registry))
+
+		response, err := handler.HandleSQSEvent(context.Background(), createSQSEvent([]string{`{"eventId":"1","clientId":"client-9","type":"notification","data":{"message":"hello"}}`}))
+
+		t.Run("then the message should be quarantined with the client's rejection code", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Empty(t, response.BatchItemFailures)
+			service.AssertNotCalled(t, "Process", mock.Anything, mock.Anything)
+			messages := quarantine.Messages()
+			if assert.Len(t, messages, 1) {
+				assert.Equal(t, "UNKNOWN_CLIENT", messages[0].RejectionCode)
+				assert.Equal(t, "client-9", messages[0].ClientID)
+			}
+		})
+	})
+
+	t.Run("when the registry cannot be read", func(t *testing.T) {
+		service := &mockService{}
+		quarantine := eventstore.NewMemoryQuarantine()
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t), WithQuarantine(quarantine), WithClientChecks(&mockRegistry{err: assert.AnError}))
+
+		response, err := handler.HandleSQSEvent(context.Background(), createSQSEvent([]string{`{"eventId":"1","clientId":"client-1","type":"notification","data":{"message":"hello"}}`}))
+
+		t.Run("then the message should be redelivered", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Len(t, response.BatchItemFailures, 1)
+			assert.Empty(t, quarantine.Messages())
+		})
+	})
+}
+
+func Test_Handler_HandleSQSEvent_WithSignatureVerification(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	assert.NoError(t, err)
+	keys := signing.NewRegistryKeyProvider(clientregistry.NewMemoryRegistry(
+		clientregistry.Client{ClientID: "client-1", SigningKey: &eventspec.VerificationKey{Algorithm: eventspec.SignatureEd25519, Key: publicKey}},
+	))
+	body := `{"eventId":"1","clientId":"client-1","type":"notification","data":{"message":"hello"}}`
+
+	t.Run("when the message is signed by the client", func(t *testing.T) {
+		service := &mockService{}
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t), WithSignatureVerification(keys))
+		service.On("Process", mock.Anything, mock.Anything).Return(nil)
+
+		message := signedMessage(body, eventspec.NewEd25519Signer(privateKey).Sign([]byte(body)))
+		response, err := handler.HandleSQSEvent(context.Background(), events.SQSEvent{Records: []events.SQSMessage{message}})
+
+		t.Run("then the event should be processed", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Empty(t, response.BatchItemFailures)
+			service.AssertNumberOfCalls(t, "Process", 1)
+		})
+	})
+
+	t.Run("when the message is not signed", func(t *testing.T) {
+		service := &mockService{}
+		quarantine := eventstore.NewMemoryQuarantine()
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t), WithQuarantine(quarantine), WithSignatureVerification(keys))
+
+		response, err := handler.HandleSQSEvent(context.Background(), createSQSEvent([]string{body}))
+
+		t.Run("then the message should be quarantined", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Empty(t, response.BatchItemFailures)
+			service.AssertNotCalled(t, "Process", mock.Anything, mock.Anything)
+			messages := quarantine.Messages()
+			if assert.Len(t, messages, 1) {
+				assert.Equal(t, "MISSING_SIGNATURE", messages[0].RejectionCode)
+			}
+		})
+	})
+
+	t.Run("when the body was changed after signing", func(t *testing.T) {
+		service := &mockService{}
+		quarantine := eventstore.NewMemoryQuarantine()
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t), WithQuarantine(quarantine), WithSignatureVerification(keys))
+
+		tampered := `{"eventId":"1","clientId":"client-1","type":"notification","data":{"message":"goodbye"}}`
+		message := signedMessage(tampered, eventspec.NewEd25519Signer(privateKey).Sign([]byte(body)))
+		response, err := handler.HandleSQSEvent(context.Background(), events.SQSEvent{Records: []events.SQSMessage{message}})
+
+		t.Run("then the message should be quarantined", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Empty(t, response.BatchItemFailures)
+			service.AssertNotCalled(t, "Process", mock.Anything, mock.Anything)
+			messages := quarantine.Messages()
+			if assert.Len(t, messages, 1) {
+				assert.Equal(t, "INVALID_SIGNATURE", messages[0].RejectionCode)
+			}
+		})
+	})
+
+	t.Run("when the key cannot be read", func(t *testing.T) {
+		service := &mockService{}
+		quarantine := eventstore.NewMemoryQuarantine()
+		failing := signing.NewRegistryKeyProvider(&mockRegistry{err: assert.AnError})
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t), WithQuarantine(quarantine), WithSignatureVerification(failing))
+
+		message := signedMessage(body, eventspec.NewEd25519Signer(privateKey).Sign([]byte(body)))
+		response, err := handler.HandleSQSEvent(context.Background(), events.SQSEvent{Records: []events.SQSMessage{message}})
+
+		t.Run("then the message should be redelivered", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Len(t, response.BatchItemFailures, 1)
+			assert.Empty(t, quarantine.Messages())
+		})
+	})
+}
+
+func Test_Handler_HandleSQSEvent_WithBatchWrites(t *testing.T) {
+	validBody1 := `{"eventId":"1","clientId":"client-1","type":"notification","data":{"message":"hello"}}`
+	invalidBody := `{"eventId":"2","clientId":"client-2","type":"unsupported","data":{"key":"value"}}`
+	validBody2 := `{"eventId":"3","clientId":"client-3","type":"notification","data":{"message":"hello"}}`
+	validBody3 := `{"eventId":"4","clientId":"client-4","type":"notification","data":{"message":"hello"}}`
+	event1 := eventspec.Event{EventID: "1", ClientID: "client-1", Type: "notification", Data: map[string]interface{}{"message": "hello"}}
+	event2 := eventspec.Event{EventID: "3", ClientID: "client-3", Type: "notification", Data: map[string]interface{}{"message": "hello"}}
+	event3 := eventspec.Event{EventID: "4", ClientID: "client-4", Type: "notification", Data: map[string]interface{}{"message": "hello"}}
+
+	t.Run("when some events in the batch fail", func(t *testing.T) {
+		service := &mockBatchService{}
+		quarantine := eventstore.NewMemoryQuarantine()
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t), WithQuarantine(quarantine), WithBatchWrites())
+		sqsEvent := createSQSEvent([]string{validBody1, invalidBody, validBody2, validBody3})
+
+		service.On("ProcessBatch", mock.Anything, withEvents(event1, event2, event3)).Return(&eventstore.BatchError{Errors: map[int]error{
+			1: assert.AnError,
+			2: failure.Permanent(assert.AnError),
+		}})
+
+		response, err := handler.HandleSQSEvent(context.Background(), sqsEvent)
+
+		t.Run("then there should be no error", func(t *testing.T) {
+			assert.NoError(t, err)
+		})
+
+		t.Run("then valid events should be processed in a single call", func(t *testing.T) {
+			service.AssertExpectations(t)
+			service.AssertNotCalled(t, "Process", mock.Anything, mock.Anything)
+		})
+
+		t.Run("then invalid and permanently failed messages should be quarantined", func(t *testing.T) {
+			var messageIDs []string
+			for _, message := range quarantine.Messages() {
+				messageIDs = append(messageIDs, message.MessageID)
+			}
+			assert.ElementsMatch(t, []string{"msg-2", "msg-4"}, messageIDs)
+		})
+
+		t.Run("then retryable failures should map back to their message IDs", func(t *testing.T) {
+			assert.Equal(t, []events.SQSBatchItemFailure{{ItemIdentifier: "msg-3"}}, response.BatchItemFailures)
+		})
+	})
+
+	t.Run("when the whole batch fails", func(t *testing.T) {
+		service := &mockBatchService{}
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t), WithBatchWrites())
+		sqsEvent := createSQSEvent([]string{validBody1, validBody2})
+
+		service.On("ProcessBatch", mock.Anything, withEvents(event1, event2)).Return(assert.AnError)
+
+		response, _ := handler.HandleSQSEvent(context.Background(), sqsEvent)
+
+		t.Run("then every message should be redelivered", func(t *testing.T) {
+			assert.Equal(t, []events.SQSBatchItemFailure{{ItemIdentifier: "msg-1"}, {ItemIdentifier: "msg-2"}}, response.BatchItemFailures)
+		})
+	})
+
+	t.Run("when the batch has a single message", func(t *testing.T) {
+		service := &mockBatchService{}
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t), WithBatchWrites())
+		sqsEvent := createSQSEvent([]string{validBody1})
+
+		service.On("Process", mock.Anything, withEvent(event1)).Return(nil)
+
+		response, _ := handler.HandleSQSEvent(context.Background(), sqsEvent)
+
+		t.Run("then the event should be processed on its own", func(t *testing.T) {
+			service.AssertExpectations(t)
+			service.AssertNotCalled(t, "ProcessBatch", mock.Anything, mock.Anything)
+			assert.Empty(t, response.BatchItemFailures)
+		})
+	})
+}
+
+func Test_Handler_HandleSQSEvent_RecordsMetadata(t *testing.T) {
+	receivedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	event := eventspec.Event{EventID: "1", ClientID: "client-1", Type: "notification", Data: map[string]interface{}{"message": "hello"}}
+
+	t.Run("when SQS reports the message attributes", func(t *testing.T) {
+		service := &mockService{}
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t), WithProcessorVersion("v1.2.3"))
+		handler.now = func() time.Time { return receivedAt }
+		sqsEvent := createSQSEvent([]string{`{"eventId":"1","clientId":"client-1","type":"notification","data":{"message":"hello"}}`})
+		sqsEvent.Records[0].Attributes = map[string]string{
+			"SentTimestamp":           "1767323041500",
+			"ApproximateReceiveCount": "3",
+		}
+
+		service.On("Process", mock.Anything, eventstore.Record{
+			Event: event,
+			Metadata: eventstore.Metadata{
+				MessageID:        "msg-1",
+				ReceivedAt:       receivedAt,
+				SentAt:           time.Date(2026, 1, 2, 3, 4, 1, 500000000, time.UTC),
+				ReceiveCount:     3,
+				ProcessorVersion: "v1.2.3",
+			},
+		}).Return(nil)
+
+		_, err := handler.HandleSQSEvent(context.Background(), sqsEvent)
+
+		t.Run("should pass the delivery metadata along with the event", func(t *testing.T) {
+			assert.NoError(t, err)
+			service.AssertExpectations(t)
+		})
+	})
+
+	t.Run("when SQS does not report the message attributes", func(t *testing.T) {
+		service := &mockService{}
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t))
+		handler.now = func() time.Time { return receivedAt }
+		sqsEvent := createSQSEvent([]string{`{"eventId":"1","clientId":"client-1","type":"notification","data":{"message":"hello"}}`})
+
+		service.On("Process", mock.Anything, eventstore.Record{
+			Event:    event,
+			Metadata: eventstore.Metadata{MessageID: "msg-1", ReceivedAt: receivedAt},
+		}).Return(nil)
+
+		_, err := handler.HandleSQSEvent(context.Background(), sqsEvent)
+
+		t.Run("should leave the missing metadata empty", func(t *testing.T) {
+			assert.NoError(t, err)
+			service.AssertExpectations(t)
+		})
+	})
+}
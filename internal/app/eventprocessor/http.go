@@ -0,0 +1,297 @@
+package eventprocessor
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/google/uuid"
+	"github.com/nivedita-verma/event-processor/internal/pkg/eventqueue"
+	"github.com/nivedita-verma/event-processor/internal/pkg/failure"
+	"github.com/nivedita-verma/event-processor/pkg/eventspec"
+)
+
+// SignatureHeader and SignatureAlgorithmHeader carry the signature of a
+// request holding a single event, made over the request body as for the
+// eventspec signature message attributes. The events of an array are signed
+// one by one instead, each over the event as it appears in the array, with
+// their signatures listed in SignaturesHeader, comma separated and in the
+// order of the array. An empty entry leaves its event unsigned. The algorithm
+// of every signature is given in SignatureAlgorithmHeader.
+const (
+	SignatureHeader          = "X-Event-Signature"
+	SignaturesHeader         = "X-Event-Signatures"
+	SignatureAlgorithmHeader = "X-Event-Signature-Algorithm"
+)
+
+const (
+	// maxRequestBytes and maxRequestEvents bound the size of a request.
+	maxRequestBytes  = 1 << 20
+	maxRequestEvents = 100
+)
+
+// EventStatus is the outcome for one event of a request.
+type EventStatus string
+
+const (
+	// EventAccepted events were processed, or enqueued for processing.
+	EventAccepted EventStatus = "accepted"
+	// EventRejected events will not be accepted if sent again unchanged.
+	EventRejected EventStatus = "rejected"
+	// EventFailed events could not be processed for now and may be sent again.
+	EventFailed EventStatus = "failed"
+)
+
+// EventResult reports the outcome for the event at Index of a request.
+type EventResult struct {
+	Index   int                        `json:"index"`
+	EventID string                     `json:"eventId,omitempty"`
+	Status  EventStatus                `json:"status"`
+	Errors  eventspec.ValidationErrors `json:"errors,omitempty"`
+	// Message describes failures other than validation errors.
+	Message string `json:"message,omitempty"`
+}
+
+// IngestResponse is the body of every HTTP ingress response. Errors and
+// Message describe failures of the request as a whole.
+type IngestResponse struct {
+	Results []EventResult              `json:"results,omitempty"`
+	Errors  eventspec.ValidationErrors `json:"errors,omitempty"`
+	Message string                     `json:"message,omitempty"`
+}
+
+// HTTPIngress accepts events posted by producers that cannot write to the
+// event queue, as a single event or a JSON array of events. Every event is
+// validated as messages of the queue are. If any is rejected, none are
+// accepted and the response is a 400 listing the rejected events; otherwise
+// the events are processed, or enqueued, and the response is a 202 with the
+// outcome for each.
+type HTTPIngress struct {
+	handler *Handler
+	sender  eventqueue.Sender
+	newID   func() string
+}
+
+type HTTPIngressOption func(*HTTPIngress)
+
+// WithSender sends valid events to the event queue with the sender, to be
+// processed from there, instead of processing them before responding.
+func WithSender(sender eventqueue.Sender) HTTPIngressOption {
+	return func(i *HTTPIngress) {
+		i.sender = sender
+	}
+}
+
+// NewHTTPIngress validates and processes events with the handler.
+func NewHTTPIngress(handler *Handler, opts ...HTTPIngressOption) *HTTPIngress {
+	ingress := &HTTPIngress{
+		handler: handler,
+		newID:   uuid.NewString,
+	}
+	for _, opt := range opts {
+		opt(ingress)
+	}
+	return ingress
+}
+
+// ServeHTTP handles POST requests of a net/http server.
+func (i *HTTPIngress) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSON(w, http.StatusMethodNotAllowed, IngestResponse{Message: "only POST is allowed"})
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	if err != nil {
+		writeJSON(w, http.StatusRequestEntityTooLarge, IngestResponse{Message: fmt.Sprintf("request body must not exceed %d bytes", maxRequestBytes)})
+		return
+	}
+	status, response := i.ingest(r.Context(), i.newID(), body, r.Header)
+	writeJSON(w, status, response)
+}
+
+// HandleAPIGatewayProxyRequest handles requests of an API Gateway Lambda
+// proxy integration.
+func (i *HTTPIngress) HandleAPIGatewayProxyRequest(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if request.HTTPMethod != http.MethodPost {
+		return apiGatewayResponse(http.StatusMethodNotAllowed, IngestResponse{Message: "only POST is allowed"})
+	}
+	body := []byte(request.Body)
+	if request.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(request.Body)
+		if err != nil {
+			return apiGatewayResponse(http.StatusBadRequest, IngestResponse{Message: "invalid base64 body"})
+		}
+		body = decoded
+	}
+	if len(body) > maxRequestBytes {
+		return apiGatewayResponse(http.StatusRequestEntityTooLarge, IngestResponse{Message: fmt.Sprintf("request body must not exceed %d bytes", maxRequestBytes)})
+	}
+	header := http.Header{}
+	for name, value := range request.Headers {
+		header.Set(name, value)
+	}
+	requestID := request.RequestContext.RequestID
+	if requestID == "" {
+		requestID = i.newID()
+	}
+	status, response := i.ingest(ctx, requestID, body, header)
+	return apiGatewayResponse(status, response)
+}
+
+// ingest validates the events of a request and, if all are valid, ingests or
+// enqueues them. Each event is delivered in an envelope identified by the
+// request ID, suffixed with its index for arrays.
+func (i *HTTPIngress) ingest(ctx context.Context, requestID string, body []byte, header http.Header) (int, IngestResponse) {
+	envelopes, errs := i.envelopes(requestID, body, header)
+	if len(errs) > 0 {
+		return http.StatusBadRequest, IngestResponse{Errors: errs}
+	}
+
+	h := i.handler
+	validated := make([]*eventspec.Event, len(envelopes))
+	var rejected []EventResult
+	for index, envelope := range envelopes {
+		event, err := h.validate(ctx, envelope)
+		if err == nil {
+			validated[index] = event
+			continue
+		}
+		if !failure.IsPermanent(err) {
+			h.logger.Errorf("failed to validate message ID %s: %v", envelope.MessageID, err)
+			return http.StatusServiceUnavailable, IngestResponse{Message: "events could not be validated, send them again later"}
+		}
+		h.logRejection(envelope.MessageID, err)
+		rejected = append(rejected, i.rejection(index, eventIDOf(envelope.Body), err))
+	}
+	if len(rejected) > 0 {
+		return http.StatusBadRequest, IngestResponse{Results: rejected}
+	}
+
+	results := make([]EventResult, len(envelopes))
+	if i.sender == nil {
+		for index, result := range h.ingest(ctx, envelopes, validated) {
+			results[index] = i.eventResult(index, result)
+		}
+		return http.StatusAccepted, IngestResponse{Results: results}
+	}
+	for index, envelope := range envelopes {
+		eventID := validated[index].EventID
+		err := i.sender.Send(ctx, envelope.Body, envelope.Attributes)
+		if err != nil {
+			h.logger.Errorf("failed to enqueue event ID %s: %v", eventID, err)
+		}
+		results[index] = i.eventResult(index, Result{
+			MessageID: envelope.MessageID,
+			EventID:   eventID,
+			Err:       err,
+			Retry:     err != nil && !failure.IsPermanent(err),
+		})
+	}
+	return http.StatusAccepted, IngestResponse{Results: results}
+}
+
+// envelopes splits the request body into one envelope per event. A single
+// event's envelope body is the request body as signed, and the body of each
+// event of an array is the event as it appears in the array.
+func (i *HTTPIngress) envelopes(requestID string, body []byte, header http.Header) ([]Envelope, eventspec.ValidationErrors) {
+	algorithm := header.Get(SignatureAlgorithmHeader)
+	trimmed := bytes.TrimSpace(body)
+	if !bytes.HasPrefix(trimmed, []byte("[")) {
+		envelope := Envelope{MessageID: requestID, Body: string(body), Source: "http"}
+		envelope.Attributes = signatureAttributes(header.Get(SignatureHeader), algorithm)
+		return []Envelope{envelope}, nil
+	}
+
+	var bodies []json.RawMessage
+	if err := json.Unmarshal(trimmed, &bodies); err != nil {
+		return nil, eventspec.ValidationErrors{{Code: eventspec.CodeMalformedJSON, Message: fmt.Sprintf("failed to unmarshal request body: %v", err)}}
+	}
+	switch {
+	case len(bodies) == 0:
+		return nil, eventspec.ValidationErrors{{Code: eventspec.CodeEmptyBody, Message: "no events in request"}}
+	case len(bodies) > maxRequestEvents:
+		return nil, eventspec.ValidationErrors{{Code: eventspec.CodeInvalidValue, Message: fmt.Sprintf("at most %d events may be sent at once", maxRequestEvents)}}
+	}
+	var signatures []string
+	if value := header.Get(SignaturesHeader); value != "" {
+		signatures = strings.Split(value, ",")
+		if len(signatures) != len(bodies) {
+			return nil, eventspec.ValidationErrors{{Code: eventspec.CodeInvalidValue, Message: fmt.Sprintf("%s holds %d signatures for %d events", SignaturesHeader, len(signatures), len(bodies))}}
+		}
+	}
+	envelopes := make([]Envelope, len(bodies))
+	for index, eventBody := range bodies {
+		envelopes[index] = Envelope{MessageID: fmt.Sprintf("%s-%d", requestID, index), Body: string(eventBody), Source: "http"}
+		if signatures != nil {
+			envelopes[index].Attributes = signatureAttributes(strings.TrimSpace(signatures[index]), algorithm)
+		}
+	}
+	return envelopes, nil
+}
+
+// signatureAttributes returns the message attributes carrying a signature and
+// its algorithm, or nil if the event is not signed.
+func signatureAttributes(signature, algorithm string) map[string]string {
+	var attributes map[string]string
+	for attribute, value := range map[string]string{
+		eventspec.SignatureAttribute:          signature,
+		eventspec.SignatureAlgorithmAttribute: algorithm,
+	} {
+		if value != "" {
+			if attributes == nil {
+				attributes = make(map[string]string)
+			}
+			attributes[attribute] = value
+		}
+	}
+	return attributes
+}
+
+// eventResult reports the outcome for the event at index.
+func (i *HTTPIngress) eventResult(index int, result Result) EventResult {
+	switch {
+	case result.Err == nil:
+		return EventResult{Index: index, EventID: result.EventID, Status: EventAccepted}
+	case result.Retry:
+		return EventResult{Index: index, EventID: result.EventID, Status: EventFailed, Message: "event could not be processed, send it again later"}
+	}
+	return i.rejection(index, result.EventID, result.Err)
+}
+
+// rejection reports a permanently failed event. Validation errors are
+// redacted as they are in logs; other errors are not described, as they may
+// reveal internals.
+func (i *HTTPIngress) rejection(index int, eventID string, err error) EventResult {
+	result := EventResult{Index: index, EventID: eventID, Status: EventRejected}
+	if errs := eventspec.AsValidationErrors(err); len(errs) > 0 {
+		result.Errors = i.handler.redactor.ValidationErrors(errs)
+	} else {
+		result.Message = "event could not be processed"
+	}
+	return result
+}
+
+func writeJSON(w http.ResponseWriter, status int, response IngestResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+func apiGatewayResponse(status int, response IngestResponse) (events.APIGatewayProxyResponse, error) {
+	body, err := json.Marshal(response)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+	return events.APIGatewayProxyResponse{
+		StatusCode: status,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(body),
+	}, nil
+}
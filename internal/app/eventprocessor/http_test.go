@@ -0,0 +1,316 @@
+package eventprocessor
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/nivedita-verma/event-processor/internal/pkg/clientregistry"
+	"github.com/nivedita-verma/event-processor/internal/pkg/eventstore"
+	"github.com/nivedita-verma/event-processor/internal/pkg/failure"
+	"github.com/nivedita-verma/event-processor/internal/pkg/signing"
+	"github.com/nivedita-verma/event-processor/pkg/eventspec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+type mockSender struct {
+	mock.Mock
+}
+
+func (m *mockSender) Send(ctx context.Context, body string, attributes map[string]string) error {
+	return m.Called(ctx, body, attributes).Error(0)
+}
+
+// countingRegistry counts the clients looked up, once per event validated.
+type countingRegistry struct {
+	clientregistry.Api
+	lookups atomic.Int64
+}
+
+func (r *countingRegistry) Get(ctx context.Context, clientID string) (clientregistry.Client, error) {
+	r.lookups.Add(1)
+	return r.Api.Get(ctx, clientID)
+}
+
+func withEventID(eventID string) interface{} {
+	return mock.MatchedBy(func(record eventstore.Record) bool {
+		return record.EventID == eventID
+	})
+}
+
+// post sends the body to the ingress and decodes the response.
+func post(t *testing.T, ingress *HTTPIngress, body string, header http.Header) (int, IngestResponse) {
+	t.Helper()
+	request := httptest.NewRequest(http.MethodPost, "/events", strings.NewReader(body))
+	for name, values := range header {
+		request.Header[name] = values
+	}
+	recorder := httptest.NewRecorder()
+	ingress.ServeHTTP(recorder, request)
+
+	response := IngestResponse{}
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	return recorder.Code, response
+}
+
+func Test_HTTPIngress_ServeHTTP(t *testing.T) {
+	validBody1 := `{"eventId":"1","clientId":"client-1","type":"notification","data":{"message":"hello"}}`
+	validBody2 := `{"eventId":"2","clientId":"client-1","type":"notification","data":{"message":"hello"}}`
+	invalidBody := `{"eventId":"3","clientId":"client-1","type":"unsupported","data":{}}`
+	newIngress := func(t *testing.T, service ServiceApi, opts ...HTTPIngressOption) *HTTPIngress {
+		ingress := NewHTTPIngress(NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t)), opts...)
+		ingress.newID = func() string { return "request-1" }
+		return ingress
+	}
+
+	t.Run("when a single valid event is posted", func(t *testing.T) {
+		service := &mockService{}
+		service.On("Process", mock.Anything, mock.MatchedBy(func(record eventstore.Record) bool {
+			return record.EventID == "1" && record.Metadata.MessageID == "request-1"
+		})).Return(nil)
+
+		status, response := post(t, newIngress(t, service), validBody1, nil)
+
+		t.Run("then it should be processed and accepted", func(t *testing.T) {
+			assert.Equal(t, http.StatusAccepted, status)
+			assert.Equal(t, []EventResult{{Index: 0, EventID: "1", Status: EventAccepted}}, response.Results)
+			service.AssertExpectations(t)
+		})
+	})
+
+	t.Run("when an array of valid events is posted", func(t *testing.T) {
+		service := &mockService{}
+		service.On("Process", mock.Anything, withEventID("1")).Return(nil)
+		service.On("Process", mock.Anything, withEventID("2")).Return(assert.AnError)
+
+		status, response := post(t, newIngress(t, service), "["+validBody1+","+validBody2+"]", nil)
+
+		t.Run("then each should be processed and reported", func(t *testing.T) {
+			assert.Equal(t, http.StatusAccepted, status)
+			assert.Equal(t, []EventResult{
+				{Index: 0, EventID: "1", Status: EventAccepted},
+				{Index: 1, EventID: "2", Status: EventFailed, Message: "event could not be processed, send it again later"},
+			}, response.Results)
+		})
+	})
+
+	t.Run("when an event fails permanently", func(t *testing.T) {
+		service := &mockService{}
+		service.On("Process", mock.Anything, mock.Anything).Return(failure.Permanent(eventspec.ValidationErrors{{Code: eventspec.CodeQuotaExceeded, Message: "over quota"}}))
+
+		status, response := post(t, newIngress(t, service), validBody1, nil)
+
+		t.Run("then it should be reported as rejected", func(t *testing.T) {
+			assert.Equal(t, http.StatusAccepted, status)
+			assert.Equal(t, []EventResult{{Index: 0, EventID: "1", Status: EventRejected, Errors: eventspec.ValidationErrors{{Code: eventspec.CodeQuotaExceeded, Message: "over quota"}}}}, response.Results)
+		})
+	})
+
+	t.Run("when any event is invalid", func(t *testing.T) {
+		service := &mockService{}
+
+		status, response := post(t, newIngress(t, service), "["+validBody1+","+invalidBody+"]", nil)
+
+		t.Run("then no event should be processed", func(t *testing.T) {
+			service.AssertNotCalled(t, "Process", mock.Anything, mock.Anything)
+		})
+
+		t.Run("then the invalid events should be reported with their errors", func(t *testing.T) {
+			assert.Equal(t, http.StatusBadRequest, status)
+			if assert.Len(t, response.Results, 1) {
+				assert.Equal(t, 1, response.Results[0].Index)
+				assert.Equal(t, "3", response.Results[0].EventID)
+				assert.Equal(t, EventRejected, response.Results[0].Status)
+				assert.Equal(t, eventspec.CodeUnsupportedType, response.Results[0].Errors[0].Code)
+			}
+		})
+	})
+
+	t.Run("when the array is malformed", func(t *testing.T) {
+		status, response := post(t, newIngress(t, &mockService{}), "["+validBody1, nil)
+
+		t.Run("then the request should be rejected", func(t *testing.T) {
+			assert.Equal(t, http.StatusBadRequest, status)
+			assert.Equal(t, eventspec.CodeMalformedJSON, response.Errors[0].Code)
+		})
+	})
+
+	t.Run("when the array is empty", func(t *testing.T) {
+		status, response := post(t, newIngress(t, &mockService{}), "[]", nil)
+
+		t.Run("then the request should be rejected", func(t *testing.T) {
+			assert.Equal(t, http.StatusBadRequest, status)
+			assert.Equal(t, eventspec.CodeEmptyBody, response.Errors[0].Code)
+		})
+	})
+
+	t.Run("when events are posted with client checks enabled", func(t *testing.T) {
+		registry := &countingRegistry{Api: clientregistry.NewMemoryRegistry(clientregistry.Client{ClientID: "client-1", Active: true, AllowedEventTypes: []string{"notification"}})}
+		service := &mockService{}
+		service.On("Process", mock.Anything, mock.Anything).Return(nil)
+		ingress := NewHTTPIngress(NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t), WithClientChecks(registry)))
+
+		status, _ := post(t, ingress, "["+validBody1+","+validBody2+"]", nil)
+
+		t.Run("then each event should be validated once", func(t *testing.T) {
+			assert.Equal(t, http.StatusAccepted, status)
+			service.AssertNumberOfCalls(t, "Process", 2)
+			assert.Equal(t, int64(2), registry.lookups.Load())
+		})
+	})
+
+	t.Run("when the client registry cannot be read", func(t *testing.T) {
+		ingress := NewHTTPIngress(NewHandler(zap.NewNop().Sugar(), &mockService{}, newSchemaRegistry(t), WithClientChecks(&mockRegistry{err: assert.AnError})))
+
+		status, _ := post(t, ingress, validBody1, nil)
+
+		t.Run("then the request should be retried later", func(t *testing.T) {
+			assert.Equal(t, http.StatusServiceUnavailable, status)
+		})
+	})
+
+	t.Run("when a sender is configured", func(t *testing.T) {
+		service := &mockService{}
+		sender := &mockSender{}
+		sender.On("Send", mock.Anything, validBody1, map[string]string{
+			eventspec.SignatureAttribute:          "c2ln",
+			eventspec.SignatureAlgorithmAttribute: "HMAC-SHA256",
+		}).Return(nil)
+
+		header := http.Header{}
+		header.Set(SignatureHeader, "c2ln")
+		header.Set(SignatureAlgorithmHeader, "HMAC-SHA256")
+		status, response := post(t, newIngress(t, service, WithSender(sender)), validBody1, header)
+
+		t.Run("then the event should be enqueued as posted, with its signature", func(t *testing.T) {
+			assert.Equal(t, http.StatusAccepted, status)
+			assert.Equal(t, []EventResult{{Index: 0, EventID: "1", Status: EventAccepted}}, response.Results)
+			sender.AssertExpectations(t)
+			service.AssertNotCalled(t, "Process", mock.Anything, mock.Anything)
+		})
+	})
+
+	t.Run("when signature verification is enabled", func(t *testing.T) {
+		secret := []byte("client-1-secret")
+		keys := signing.NewRegistryKeyProvider(clientregistry.NewMemoryRegistry(
+			clientregistry.Client{ClientID: "client-1", SigningKey: &eventspec.VerificationKey{Algorithm: eventspec.SignatureHMACSHA256, Key: secret}},
+		))
+		signer := eventspec.NewHMACSigner(secret)
+		signature := func(body string) string {
+			return signer.Sign([]byte(body))[eventspec.SignatureAttribute]
+		}
+		newVerifyingIngress := func(service ServiceApi) *HTTPIngress {
+			return NewHTTPIngress(NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t), WithSignatureVerification(keys)))
+		}
+
+		t.Run("and every event of an array is signed", func(t *testing.T) {
+			service := &mockService{}
+			service.On("Process", mock.Anything, mock.Anything).Return(nil)
+			header := http.Header{}
+			header.Set(SignaturesHeader, signature(validBody1)+", "+signature(validBody2))
+			header.Set(SignatureAlgorithmHeader, string(eventspec.SignatureHMACSHA256))
+
+			status, response := post(t, newVerifyingIngress(service), "[\n  "+validBody1+",\n  "+validBody2+"\n]", header)
+
+			t.Run("then each event should be verified and accepted", func(t *testing.T) {
+				assert.Equal(t, http.StatusAccepted, status)
+				assert.Equal(t, []EventResult{
+					{Index: 0, EventID: "1", Status: EventAccepted},
+					{Index: 1, EventID: "2", Status: EventAccepted},
+				}, response.Results)
+			})
+		})
+
+		t.Run("and an event of an array is not signed", func(t *testing.T) {
+			header := http.Header{}
+			header.Set(SignaturesHeader, signature(validBody1)+",")
+			header.Set(SignatureAlgorithmHeader, string(eventspec.SignatureHMACSHA256))
+
+			status, response := post(t, newVerifyingIngress(&mockService{}), "["+validBody1+","+validBody2+"]", header)
+
+			t.Run("then the request should be rejected for it", func(t *testing.T) {
+				assert.Equal(t, http.StatusBadRequest, status)
+				if assert.Len(t, response.Results, 1) {
+					assert.Equal(t, 1, response.Results[0].Index)
+					assert.Equal(t, eventspec.CodeMissingSignature, response.Results[0].Errors[0].Code)
+				}
+			})
+		})
+
+		t.Run("and the signatures do not match the events of an array", func(t *testing.T) {
+			header := http.Header{}
+			header.Set(SignaturesHeader, signature(validBody1))
+
+			status, response := post(t, newVerifyingIngress(&mockService{}), "["+validBody1+","+validBody2+"]", header)
+
+			t.Run("then the request should be rejected", func(t *testing.T) {
+				assert.Equal(t, http.StatusBadRequest, status)
+				assert.Equal(t, eventspec.ValidationErrors{{Code: eventspec.CodeInvalidValue, Message: "X-Event-Signatures holds 1 signatures for 2 events"}}, response.Errors)
+			})
+		})
+	})
+
+	t.Run("when the method is not POST", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		newIngress(t, &mockService{}).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+		t.Run("then it should not be allowed", func(t *testing.T) {
+			assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+			assert.Equal(t, http.MethodPost, recorder.Header().Get("Allow"))
+		})
+	})
+}
+
+func Test_HTTPIngress_HandleAPIGatewayProxyRequest(t *testing.T) {
+	body := `{"eventId":"1","clientId":"client-1","type":"notification","data":{"message":"hello"}}`
+
+	t.Run("when a base64 encoded event is posted", func(t *testing.T) {
+		sender := &mockSender{}
+		sender.On("Send", mock.Anything, body, map[string]string{eventspec.SignatureAttribute: "c2ln"}).Return(nil)
+		ingress := NewHTTPIngress(NewHandler(zap.NewNop().Sugar(), &mockService{}, newSchemaRegistry(t)), WithSender(sender))
+
+		response, err := ingress.HandleAPIGatewayProxyRequest(context.Background(), events.APIGatewayProxyRequest{
+			HTTPMethod:      http.MethodPost,
+			Body:            base64.StdEncoding.EncodeToString([]byte(body)),
+			IsBase64Encoded: true,
+			Headers:         map[string]string{"x-event-signature": "c2ln"},
+			RequestContext:  events.APIGatewayProxyRequestContext{RequestID: "request-1"},
+		})
+
+		t.Run("then it should be accepted", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusAccepted, response.StatusCode)
+			assert.Equal(t, "application/json", response.Headers["Content-Type"])
+			assert.JSONEq(t, `{"results":[{"index":0,"eventId":"1","status":"accepted"}]}`, response.Body)
+			sender.AssertExpectations(t)
+		})
+	})
+
+	t.Run("when an invalid event is posted", func(t *testing.T) {
+		ingress := NewHTTPIngress(NewHandler(zap.NewNop().Sugar(), &mockService{}, newSchemaRegistry(t)))
+
+		response, err := ingress.HandleAPIGatewayProxyRequest(context.Background(), events.APIGatewayProxyRequest{
+			HTTPMethod: http.MethodPost,
+			Body:       `{"eventId":"1","clientId":"client-1","type":"notification"}`,
+		})
+
+		t.Run("then it should be rejected with structured errors", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+			result := IngestResponse{}
+			assert.NoError(t, json.Unmarshal([]byte(response.Body), &result))
+			if assert.Len(t, result.Results, 1) {
+				assert.Equal(t, eventspec.CodeMissingField, result.Results[0].Errors[0].Code)
+			}
+		})
+	})
+}
@@ -0,0 +1,144 @@
+package eventprocessor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/nivedita-verma/event-processor/internal/pkg/failure"
+	"github.com/nivedita-verma/event-processor/pkg/eventspec"
+)
+
+// Envelope is an event as delivered by a transport, before it is decoded.
+// Transport adapters convert what they receive into envelopes and hand them
+// to Ingest, so every event is validated and processed the same way whatever
+// it arrived by.
+type Envelope struct {
+	// MessageID identifies the delivery in logs and the quarantine.
+	MessageID string
+	// Body is the event, as sent by the producer.
+	Body string
+	// Attributes are sent alongside the body, such as the event's signature in
+	// eventspec.SignatureAttribute and eventspec.SignatureAlgorithmAttribute.
+	Attributes map[string]string
+	// ReceiveCount is how many times the event has been delivered, or 0 if the
+	// transport does not report it.
+	ReceiveCount int
+	// Source names the transport, e.g. aws:sqs.
+	Source string
+	// SentAt is when the producer sent the event, if the transport reports it.
+	SentAt time.Time
+}
+
+// Result is the outcome of ingesting an envelope.
+type Result struct {
+	MessageID string
+	// EventID identifies the event, if its body could be decoded.
+	EventID string
+	// Err is why the event was not accepted, or nil if it was. Events failing
+	// permanently have been quarantined, unless Retry is set.
+	Err error
+	// Retry reports whether the envelope should be delivered again.
+	Retry bool
+}
+
+// errDeferred is the error of envelopes left for redelivery behind an earlier
+// envelope of the same client.
+var errDeferred = errors.New("deferred until an earlier message for the same client succeeds")
+
+// Ingest validates and processes envelopes, returning a result per envelope
+// in the same order. Envelopes are processed with a single call to the
+// service's ProcessBatch when batch writes are enabled, and concurrently
+// otherwise.
+func (h *Handler) Ingest(ctx context.Context, envelopes []Envelope) []Result {
+	return h.ingest(ctx, envelopes, nil)
+}
+
+// ingest processes envelopes as Ingest does. Callers that have already
+// validated the envelopes pass their events, so they are not validated again;
+// otherwise events is nil.
+func (h *Handler) ingest(ctx context.Context, envelopes []Envelope, events []*eventspec.Event) []Result {
+	if batchService, ok := h.service.(BatchServiceApi); ok && h.batchWrites && len(envelopes) > 1 {
+		return h.processBatch(ctx, batchService, envelopes, events)
+	}
+	return h.processConcurrently(ctx, envelopes, events)
+}
+
+// eventOf returns the event of the envelope at index, validating it unless
+// events holds it already.
+func (h *Handler) eventOf(ctx context.Context, envelopes []Envelope, events []*eventspec.Event, index int) (*eventspec.Event, error) {
+	if events != nil {
+		return events[index], nil
+	}
+	return h.validate(ctx, envelopes[index])
+}
+
+// ingestEnvelope validates and processes the envelope at index.
+func (h *Handler) ingestEnvelope(ctx context.Context, envelopes []Envelope, events []*eventspec.Event, index int) Result {
+	envelope := envelopes[index]
+	h.logger.Infof("Received message ID: %s, from source %s", envelope.MessageID, envelope.Source)
+	receivedAt := h.now()
+	event, err := h.eventOf(ctx, envelopes, events, index)
+	if err != nil {
+		return h.reject(ctx, envelope, err)
+	}
+	if err = h.service.Process(ctx, h.newRecord(envelope, *event, receivedAt)); err != nil {
+		h.logger.Errorf("failed to process event ID %s: %v", event.EventID, err)
+	}
+	return h.result(ctx, envelope, event.EventID, err)
+}
+
+// reject logs why an envelope was rejected and quarantines it if the failure
+// is permanent. Adapters reject envelopes they cannot decode through here.
+func (h *Handler) reject(ctx context.Context, envelope Envelope, err error) Result {
+	h.logRejection(envelope.MessageID, err)
+	return h.result(ctx, envelope, eventIDOf(envelope.Body), err)
+}
+
+// result reports the outcome of an envelope, quarantining permanent failures.
+func (h *Handler) result(ctx context.Context, envelope Envelope, eventID string, err error) Result {
+	return Result{
+		MessageID: envelope.MessageID,
+		EventID:   eventID,
+		Err:       err,
+		Retry:     err != nil && !h.acknowledgeFailure(ctx, envelope, err),
+	}
+}
+
+// acknowledgeFailure quarantines permanently failed envelopes and reports
+// whether the envelope can be acknowledged. Retryable failures, and permanent
+// failures that could not be quarantined, are left to be delivered again.
+func (h *Handler) acknowledgeFailure(ctx context.Context, envelope Envelope, err error) bool {
+	if !failure.IsPermanent(err) {
+		h.logger.Warnf("Message ID %s failed with a retryable error and will be redelivered", envelope.MessageID)
+		return false
+	}
+	if qErr := h.quarantine.Quarantine(ctx, h.newQuarantinedMessage(envelope, err)); qErr != nil {
+		h.logger.Errorf("failed to quarantine message ID %s: %v", envelope.MessageID, qErr)
+		return false
+	}
+	return true
+}
+
+// retrying reports whether any of the results is to be delivered again.
+func retrying(results []Result) bool {
+	for _, result := range results {
+		if result.Retry {
+			return true
+		}
+	}
+	return false
+}
+
+// eventIDOf extracts the event ID from a body, returning an empty string if
+// the body cannot be decoded.
+func eventIDOf(body string) string {
+	var envelope struct {
+		EventID string `json:"eventId"`
+	}
+	if err := json.Unmarshal([]byte(body), &envelope); err != nil {
+		return ""
+	}
+	return envelope.EventID
+}
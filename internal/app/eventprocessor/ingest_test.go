@@ -0,0 +1,89 @@
+package eventprocessor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nivedita-verma/event-processor/internal/pkg/eventstore"
+	"github.com/nivedita-verma/event-processor/internal/pkg/failure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func Test_Handler_Ingest(t *testing.T) {
+	envelopes := []Envelope{
+		{MessageID: "msg-1", Body: notificationBody("1", "client-1"), Source: "test", ReceiveCount: 2, SentAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{MessageID: "msg-2", Body: `{"eventId":"2","clientId":"client-1","type":"unsupported","data":{}}`, Source: "test"},
+		{MessageID: "msg-3", Body: notificationBody("3", "client-1"), Source: "test"},
+		{MessageID: "msg-4", Body: notificationBody("4", "client-1"), Source: "test"},
+	}
+
+	t.Run("when envelopes succeed and fail", func(t *testing.T) {
+		service := &mockService{}
+		quarantine := eventstore.NewMemoryQuarantine()
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t), WithQuarantine(quarantine))
+		service.On("Process", mock.Anything, withEventID("1")).Return(nil)
+		service.On("Process", mock.Anything, withEventID("3")).Return(assert.AnError)
+		service.On("Process", mock.Anything, withEventID("4")).Return(failure.Permanent(assert.AnError))
+
+		results := handler.Ingest(context.Background(), envelopes)
+
+		t.Run("then a result should be returned per envelope in order", func(t *testing.T) {
+			if assert.Len(t, results, 4) {
+				assert.Equal(t, Result{MessageID: "msg-1", EventID: "1"}, results[0])
+				assert.Equal(t, "2", results[1].EventID)
+				assert.Error(t, results[1].Err)
+				assert.False(t, results[1].Retry)
+				assert.Equal(t, Result{MessageID: "msg-3", EventID: "3", Err: assert.AnError, Retry: true}, results[2])
+				assert.ErrorIs(t, results[3].Err, assert.AnError)
+				assert.False(t, results[3].Retry)
+			}
+		})
+
+		t.Run("then permanent failures should be quarantined", func(t *testing.T) {
+			var quarantined []string
+			for _, message := range quarantine.Messages() {
+				quarantined = append(quarantined, message.MessageID)
+			}
+			assert.ElementsMatch(t, []string{"msg-2", "msg-4"}, quarantined)
+		})
+
+		t.Run("then records should carry the envelope's metadata", func(t *testing.T) {
+			record := service.Calls[0].Arguments.Get(1).(eventstore.Record)
+			assert.Equal(t, "msg-1", record.Metadata.MessageID)
+			assert.Equal(t, 2, record.Metadata.ReceiveCount)
+			assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), record.Metadata.SentAt)
+		})
+	})
+
+	t.Run("when ordering by client and an envelope is to be retried", func(t *testing.T) {
+		service := &mockService{}
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t), WithClientOrdering())
+		service.On("Process", mock.Anything, withEventID("1")).Return(assert.AnError)
+
+		results := handler.Ingest(context.Background(), []Envelope{envelopes[0], envelopes[2]})
+
+		t.Run("then later envelopes of the client should be deferred", func(t *testing.T) {
+			assert.Equal(t, Result{MessageID: "msg-3", EventID: "3", Err: errDeferred, Retry: true}, results[1])
+			service.AssertNumberOfCalls(t, "Process", 1)
+		})
+	})
+
+	t.Run("when batch writes are enabled", func(t *testing.T) {
+		service := &mockBatchService{}
+		quarantine := eventstore.NewMemoryQuarantine()
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t), WithQuarantine(quarantine), WithBatchWrites())
+		service.On("ProcessBatch", mock.Anything, mock.Anything).Return(&eventstore.BatchError{Errors: map[int]error{1: assert.AnError}})
+
+		results := handler.Ingest(context.Background(), envelopes[:3])
+
+		t.Run("then failures should be mapped back to their envelopes", func(t *testing.T) {
+			assert.Equal(t, Result{MessageID: "msg-1", EventID: "1"}, results[0])
+			assert.False(t, results[1].Retry)
+			assert.Equal(t, Result{MessageID: "msg-3", EventID: "3", Err: assert.AnError, Retry: true}, results[2])
+			assert.Len(t, quarantine.Messages(), 1)
+		})
+	})
+}
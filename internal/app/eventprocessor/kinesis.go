@@ -0,0 +1,113 @@
+package eventprocessor
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/nivedita-verma/event-processor/internal/pkg/failure"
+	"github.com/nivedita-verma/event-processor/pkg/eventspec"
+)
+
+// maxKinesisDataBytes bounds the decompressed data of a Kinesis record.
+const maxKinesisDataBytes = 10 << 20
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// HandleKinesisEvent validates and processes the events of Kinesis records in
+// order. Record data holds an event or a JSON array of events, optionally gzip
+// compressed. Permanent failures are quarantined as for SQS. Processing stops
+// at the first record to be retried and only its sequence number is reported,
+// so Lambda retries from that record and the events of a partition key are
+// never processed out of order. Events of the record processed before the
+// failure are processed again on retry, which the store treats as duplicates.
+// Kinesis records carry no attributes, so their events cannot be signed.
+func (h *Handler) HandleKinesisEvent(ctx context.Context, kinesisEvent events.KinesisEvent) (events.KinesisEventResponse, error) {
+	response := events.KinesisEventResponse{
+		BatchItemFailures: []events.KinesisBatchItemFailure{},
+	}
+	for _, record := range kinesisEvent.Records {
+		if h.ingestKinesisRecord(ctx, record) {
+			response.BatchItemFailures = append(response.BatchItemFailures, events.KinesisBatchItemFailure{ItemIdentifier: record.Kinesis.SequenceNumber})
+			break
+		}
+	}
+	return response, nil
+}
+
+// ingestKinesisRecord ingests the events of a record one at a time, in order,
+// reporting whether the record should be retried.
+func (h *Handler) ingestKinesisRecord(ctx context.Context, record events.KinesisEventRecord) bool {
+	envelopes, err := kinesisEnvelopes(record)
+	if err != nil {
+		envelope := kinesisEnvelope(record, record.Kinesis.SequenceNumber, base64.StdEncoding.EncodeToString(record.Kinesis.Data))
+		return h.reject(ctx, envelope, err).Retry
+	}
+	for _, envelope := range envelopes {
+		if retrying(h.Ingest(ctx, []Envelope{envelope})) {
+			return true
+		}
+	}
+	return false
+}
+
+// kinesisEnvelopes decodes the events of a record into envelopes identified
+// by the record's sequence number, suffixed with their index for arrays. Data
+// that cannot be decoded is a permanent failure.
+func kinesisEnvelopes(record events.KinesisEventRecord) ([]Envelope, error) {
+	data := record.Kinesis.Data
+	if bytes.HasPrefix(data, gzipMagic) {
+		decompressed, err := gunzip(data)
+		if err != nil {
+			return nil, failure.Permanent(eventspec.ValidationErrors{{Code: eventspec.CodeMalformedJSON, Message: fmt.Sprintf("failed to decompress record data: %v", err)}})
+		}
+		data = decompressed
+	}
+
+	sequenceNumber := record.Kinesis.SequenceNumber
+	trimmed := bytes.TrimSpace(data)
+	if !bytes.HasPrefix(trimmed, []byte("[")) {
+		return []Envelope{kinesisEnvelope(record, sequenceNumber, string(data))}, nil
+	}
+	var bodies []json.RawMessage
+	if err := json.Unmarshal(trimmed, &bodies); err != nil {
+		return nil, failure.Permanent(eventspec.ValidationErrors{{Code: eventspec.CodeMalformedJSON, Message: fmt.Sprintf("failed to unmarshal record data: %v", err)}})
+	}
+	envelopes := make([]Envelope, len(bodies))
+	for i, body := range bodies {
+		envelopes[i] = kinesisEnvelope(record, fmt.Sprintf("%s-%d", sequenceNumber, i), string(body))
+	}
+	return envelopes, nil
+}
+
+// kinesisEnvelope carries an event of a record, with the time the record
+// arrived in the stream as the time it was sent.
+func kinesisEnvelope(record events.KinesisEventRecord, messageID, body string) Envelope {
+	return Envelope{
+		MessageID: messageID,
+		Body:      body,
+		Source:    "aws:kinesis",
+		SentAt:    record.Kinesis.ApproximateArrivalTimestamp.Time,
+	}
+}
+
+func gunzip(data []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	decompressed, err := io.ReadAll(io.LimitReader(reader, maxKinesisDataBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(decompressed) > maxKinesisDataBytes {
+		return nil, fmt.Errorf("decompressed data exceeds %d bytes", maxKinesisDataBytes)
+	}
+	return decompressed, nil
+}
@@ -0,0 +1,134 @@
+package eventprocessor
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/nivedita-verma/event-processor/internal/pkg/eventstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func newKinesisRecord(sequenceNumber string, data []byte) events.KinesisEventRecord {
+	return events.KinesisEventRecord{
+		EventSource: "aws:kinesis",
+		Kinesis: events.KinesisRecord{
+			SequenceNumber:              sequenceNumber,
+			PartitionKey:                "client-1",
+			Data:                        data,
+			ApproximateArrivalTimestamp: events.SecondsEpochTime{Time: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		},
+	}
+}
+
+func gzipped(t *testing.T, data string) []byte {
+	t.Helper()
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	_, err := writer.Write([]byte(data))
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+	return buffer.Bytes()
+}
+
+func Test_Handler_HandleKinesisEvent(t *testing.T) {
+	body1 := `{"eventId":"1","clientId":"client-1","type":"notification","data":{"message":"hello"}}`
+	body2 := `{"eventId":"2","clientId":"client-1","type":"notification","data":{"message":"hello"}}`
+	body3 := `{"eventId":"3","clientId":"client-1","type":"notification","data":{"message":"hello"}}`
+
+	t.Run("when every record is valid", func(t *testing.T) {
+		service := &mockService{}
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t))
+		var processed []string
+		service.On("Process", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			processed = append(processed, args.Get(1).(eventstore.Record).EventID)
+		}).Return(nil)
+
+		response, err := handler.HandleKinesisEvent(context.Background(), events.KinesisEvent{Records: []events.KinesisEventRecord{
+			newKinesisRecord("100", []byte(body1)),
+			newKinesisRecord("101", gzipped(t, "["+body2+","+body3+"]")),
+		}})
+
+		t.Run("then every event should be processed in order", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Empty(t, response.BatchItemFailures)
+			assert.Equal(t, []string{"1", "2", "3"}, processed)
+		})
+
+		t.Run("then events should be identified by sequence number", func(t *testing.T) {
+			record := service.Calls[1].Arguments.Get(1).(eventstore.Record)
+			assert.Equal(t, "101-0", record.Metadata.MessageID)
+			assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), record.Metadata.SentAt)
+		})
+	})
+
+	t.Run("when a record fails with a retryable error", func(t *testing.T) {
+		service := &mockService{}
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t))
+		service.On("Process", mock.Anything, withEventID("1")).Return(nil)
+		service.On("Process", mock.Anything, withEventID("2")).Return(assert.AnError)
+
+		response, err := handler.HandleKinesisEvent(context.Background(), events.KinesisEvent{Records: []events.KinesisEventRecord{
+			newKinesisRecord("100", []byte(body1)),
+			newKinesisRecord("101", []byte(body2)),
+			newKinesisRecord("102", []byte(body3)),
+		}})
+
+		t.Run("then its sequence number should be reported", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Equal(t, []events.KinesisBatchItemFailure{{ItemIdentifier: "101"}}, response.BatchItemFailures)
+		})
+
+		t.Run("then later records should not be processed", func(t *testing.T) {
+			service.AssertNotCalled(t, "Process", mock.Anything, withEventID("3"))
+		})
+	})
+
+	t.Run("when a record is invalid", func(t *testing.T) {
+		service := &mockService{}
+		quarantine := eventstore.NewMemoryQuarantine()
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t), WithQuarantine(quarantine))
+		service.On("Process", mock.Anything, mock.Anything).Return(nil)
+
+		response, err := handler.HandleKinesisEvent(context.Background(), events.KinesisEvent{Records: []events.KinesisEventRecord{
+			newKinesisRecord("100", []byte(`{"eventId":"1","clientId":"client-1","type":"unsupported","data":{}}`)),
+			newKinesisRecord("101", []byte(body2)),
+		}})
+
+		t.Run("then it should be quarantined and later records processed", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Empty(t, response.BatchItemFailures)
+			messages := quarantine.Messages()
+			if assert.Len(t, messages, 1) {
+				assert.Equal(t, "100", messages[0].MessageID)
+				assert.Equal(t, "UNSUPPORTED_TYPE", messages[0].RejectionCode)
+			}
+			service.AssertNumberOfCalls(t, "Process", 1)
+		})
+	})
+
+	t.Run("when record data cannot be decompressed", func(t *testing.T) {
+		service := &mockService{}
+		quarantine := eventstore.NewMemoryQuarantine()
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t), WithQuarantine(quarantine))
+
+		response, err := handler.HandleKinesisEvent(context.Background(), events.KinesisEvent{Records: []events.KinesisEventRecord{
+			newKinesisRecord("100", []byte{0x1f, 0x8b, 0x00}),
+		}})
+
+		t.Run("then it should be quarantined", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Empty(t, response.BatchItemFailures)
+			messages := quarantine.Messages()
+			if assert.Len(t, messages, 1) {
+				assert.Equal(t, "MALFORMED_JSON", messages[0].RejectionCode)
+				assert.Equal(t, "[REDACTED] (body of 4 bytes that is not a JSON object)", messages[0].Body)
+			}
+		})
+	})
+}
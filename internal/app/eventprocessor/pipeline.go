@@ -0,0 +1,65 @@
+package eventprocessor
+
+import (
+	"context"
+
+	"github.com/nivedita-verma/event-processor/internal/pkg/eventstore"
+	"github.com/nivedita-verma/event-processor/pkg/eventspec"
+)
+
+// Decision is the outcome of a Stage for an event.
+type Decision struct {
+	drop  bool
+	route eventspec.EventType
+}
+
+var (
+	// Continue passes the event on to the next stage.
+	Continue = Decision{}
+	// Drop acknowledges the event without running the remaining stages or
+	// routing it.
+	Drop = Decision{drop: true}
+)
+
+// Reroute sends the event to the given route instead of the route for its
+// type, once the remaining stages have run. A later Reroute takes precedence.
+func Reroute(route eventspec.EventType) Decision {
+	return Decision{route: route}
+}
+
+// Dropped reports whether the event was dropped.
+func (d Decision) Dropped() bool {
+	return d.drop
+}
+
+// Route returns the route the event was rerouted to, if any.
+func (d Decision) Route() (eventspec.EventType, bool) {
+	return d.route, d.route != ""
+}
+
+// Stage inspects an event before it is routed. It may modify the record in
+// place, and decides whether the event continues, is dropped or is rerouted.
+// Errors are handled as for Processor.
+type Stage func(ctx context.Context, record *eventstore.Record) (Decision, error)
+
+// Pipeline runs stages over an event in order.
+type Pipeline []Stage
+
+// Run runs the stages until one drops the event or fails. It returns Drop, or
+// the last Reroute made, or Continue.
+func (p Pipeline) Run(ctx context.Context, record *eventstore.Record) (Decision, error) {
+	result := Continue
+	for _, stage := range p {
+		decision, err := stage(ctx, record)
+		if err != nil {
+			return Continue, err
+		}
+		if decision.Dropped() {
+			return Drop, nil
+		}
+		if _, ok := decision.Route(); ok {
+			result = decision
+		}
+	}
+	return result, nil
+}
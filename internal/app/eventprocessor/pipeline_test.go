@@ -0,0 +1,73 @@
+package eventprocessor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nivedita-verma/event-processor/internal/pkg/eventstore"
+	"github.com/nivedita-verma/event-processor/pkg/eventspec"
+	"github.com/stretchr/testify/assert"
+)
+
+func decide(decision Decision, err error, ran *[]int, id int) Stage {
+	return func(ctx context.Context, record *eventstore.Record) (Decision, error) {
+		*ran = append(*ran, id)
+		return decision, err
+	}
+}
+
+func Test_Pipeline_Run(t *testing.T) {
+	t.Run("when every stage continues", func(t *testing.T) {
+		record := &eventstore.Record{}
+		tag := func(ctx context.Context, record *eventstore.Record) (Decision, error) {
+			record.Data = map[string]interface{}{"tagged": true}
+			return Continue, nil
+		}
+
+		decision, err := Pipeline{tag}.Run(context.Background(), record)
+
+		t.Run("should continue with the changes made by the stages", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Equal(t, Continue, decision)
+			assert.Equal(t, map[string]interface{}{"tagged": true}, record.Data)
+		})
+	})
+
+	t.Run("when a stage drops the event", func(t *testing.T) {
+		var ran []int
+		decision, err := Pipeline{decide(Continue, nil, &ran, 1), decide(Drop, nil, &ran, 2), decide(Continue, nil, &ran, 3)}.Run(context.Background(), &eventstore.Record{})
+
+		t.Run("should drop it without running the remaining stages", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.True(t, decision.Dropped())
+			assert.Equal(t, []int{1, 2}, ran)
+		})
+	})
+
+	t.Run("when stages reroute the event", func(t *testing.T) {
+		var ran []int
+		decision, err := Pipeline{
+			decide(Reroute("review"), nil, &ran, 1),
+			decide(Reroute("urgent"), nil, &ran, 2),
+			decide(Continue, nil, &ran, 3),
+		}.Run(context.Background(), &eventstore.Record{})
+
+		t.Run("should run the remaining stages and take the last route", func(t *testing.T) {
+			assert.NoError(t, err)
+			route, ok := decision.Route()
+			assert.True(t, ok)
+			assert.Equal(t, eventspec.EventType("urgent"), route)
+			assert.Equal(t, []int{1, 2, 3}, ran)
+		})
+	})
+
+	t.Run("when a stage fails", func(t *testing.T) {
+		var ran []int
+		_, err := Pipeline{decide(Continue, assert.AnError, &ran, 1), decide(Continue, nil, &ran, 2)}.Run(context.Background(), &eventstore.Record{})
+
+		t.Run("should return the error without running the remaining stages", func(t *testing.T) {
+			assert.ErrorIs(t, err, assert.AnError)
+			assert.Equal(t, []int{1}, ran)
+		})
+	})
+}
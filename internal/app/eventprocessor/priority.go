@@ -0,0 +1,92 @@
+package eventprocessor
+
+import (
+	"context"
+
+	"github.com/nivedita-verma/event-processor/internal/pkg/eventqueue"
+	"github.com/nivedita-verma/event-processor/internal/pkg/eventstore"
+	"github.com/nivedita-verma/event-processor/pkg/eventspec"
+)
+
+// FastPathRoute is the route critical events are sent to by RouteCritical.
+const FastPathRoute eventspec.EventType = "fastPath"
+
+// PriorityRules derive the priority of an event from its type and data.
+type PriorityRules struct {
+	// HighTransactionAmount and CriticalTransactionAmount are the amounts at
+	// or above which a transaction is of high or critical priority.
+	HighTransactionAmount     float64
+	CriticalTransactionAmount float64
+}
+
+func DefaultPriorityRules() PriorityRules {
+	return PriorityRules{
+		HighTransactionAmount:     10000,
+		CriticalTransactionAmount: 100000,
+	}
+}
+
+// alertPriorities maps the severity of a monitoring alert to its priority.
+var alertPriorities = map[string]eventspec.Priority{
+	"critical": eventspec.PriorityCritical,
+	"high":     eventspec.PriorityHigh,
+	"medium":   eventspec.PriorityNormal,
+	"low":      eventspec.PriorityLow,
+}
+
+// Classify returns the priority of the event. Monitoring alerts take the
+// priority of their severity, transactions are ranked by amount and
+// notifications are of low priority. Anything else is of normal priority.
+func (r PriorityRules) Classify(event eventspec.Event) eventspec.Priority {
+	switch eventspec.EventType(event.Type) {
+	case eventspec.MonitoringAlert:
+		severity, _ := event.Data["severity"].(string)
+		if priority, ok := alertPriorities[severity]; ok {
+			return priority
+		}
+	case eventspec.Transaction:
+		amount, _ := event.Data["amount"].(float64)
+		switch {
+		case amount >= r.CriticalTransactionAmount:
+			return eventspec.PriorityCritical
+		case amount >= r.HighTransactionAmount:
+			return eventspec.PriorityHigh
+		}
+	case eventspec.Notification:
+		return eventspec.PriorityLow
+	}
+	return eventspec.PriorityNormal
+}
+
+// Prioritise sets the priority of every event using the given rules.
+func Prioritise(rules PriorityRules) Stage {
+	return func(ctx context.Context, record *eventstore.Record) (Decision, error) {
+		record.Priority = rules.Classify(record.Event)
+		return Continue, nil
+	}
+}
+
+// RouteCritical reroutes events of critical priority to FastPathRoute. It
+// must run after Prioritise.
+func RouteCritical() Stage {
+	return func(ctx context.Context, record *eventstore.Record) (Decision, error) {
+		if record.Priority == eventspec.PriorityCritical {
+			return Reroute(FastPathRoute), nil
+		}
+		return Continue, nil
+	}
+}
+
+// WithFastPath registers FastPathRoute, on which events are processed by the
+// route of their type, persisting them to the store of that type, and then by
+// the given processors, e.g. PublishTo the fast path queue.
+func WithFastPath(processors ...Processor) ServiceOption {
+	return func(s *Service) {
+		s.router.Handle(FastPathRoute, append([]Processor{s.router}, processors...)...)
+	}
+}
+
+// PublishTo returns a processor that publishes events to a queue.
+func PublishTo(queue eventqueue.Api) Processor {
+	return ProcessorFunc(queue.Publish)
+}
@@ -0,0 +1,116 @@
+package eventprocessor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nivedita-verma/event-processor/internal/pkg/eventstore"
+	"github.com/nivedita-verma/event-processor/pkg/eventspec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_PriorityRules_Classify(t *testing.T) {
+	rules := DefaultPriorityRules()
+	cases := []struct {
+		name     string
+		event    eventspec.Event
+		expected eventspec.Priority
+	}{
+		{"critical alert", eventspec.Event{Type: "monitoringAlert", Data: map[string]interface{}{"severity": "critical"}}, eventspec.PriorityCritical},
+		{"high alert", eventspec.Event{Type: "monitoringAlert", Data: map[string]interface{}{"severity": "high"}}, eventspec.PriorityHigh},
+		{"medium alert", eventspec.Event{Type: "monitoringAlert", Data: map[string]interface{}{"severity": "medium"}}, eventspec.PriorityNormal},
+		{"low alert", eventspec.Event{Type: "monitoringAlert", Data: map[string]interface{}{"severity": "low"}}, eventspec.PriorityLow},
+		{"alert without severity", eventspec.Event{Type: "monitoringAlert", Data: map[string]interface{}{}}, eventspec.PriorityNormal},
+		{"transaction at the critical amount", eventspec.Event{Type: "transaction", Data: map[string]interface{}{"amount": 100000.0}}, eventspec.PriorityCritical},
+		{"transaction at the high amount", eventspec.Event{Type: "transaction", Data: map[string]interface{}{"amount": 10000.0}}, eventspec.PriorityHigh},
+		{"small transaction", eventspec.Event{Type: "transaction", Data: map[string]interface{}{"amount": 9999.99}}, eventspec.PriorityNormal},
+		{"notification", eventspec.Event{Type: "notification", Data: map[string]interface{}{"message": "hello"}}, eventspec.PriorityLow},
+		{"unknown type", eventspec.Event{Type: "unknown"}, eventspec.PriorityNormal},
+	}
+
+	for _, c := range cases {
+		t.Run("should classify a "+c.name+" as "+string(c.expected), func(t *testing.T) {
+			assert.Equal(t, c.expected, rules.Classify(c.event))
+		})
+	}
+}
+
+func Test_Prioritise(t *testing.T) {
+	record := &eventstore.Record{Event: eventspec.Event{Type: "monitoringAlert", Data: map[string]interface{}{"severity": "high"}}}
+
+	decision, err := Prioritise(DefaultPriorityRules())(context.Background(), record)
+
+	t.Run("should set the priority and continue", func(t *testing.T) {
+		assert.NoError(t, err)
+		assert.Equal(t, Continue, decision)
+		assert.Equal(t, eventspec.PriorityHigh, record.Priority)
+	})
+}
+
+func Test_RouteCritical(t *testing.T) {
+	t.Run("when the event is critical", func(t *testing.T) {
+		decision, err := RouteCritical()(context.Background(), &eventstore.Record{Priority: eventspec.PriorityCritical})
+
+		t.Run("should reroute it to the fast path", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Equal(t, Reroute(FastPathRoute), decision)
+		})
+	})
+
+	t.Run("when the event is not critical", func(t *testing.T) {
+		decision, err := RouteCritical()(context.Background(), &eventstore.Record{Priority: eventspec.PriorityHigh})
+
+		t.Run("should continue", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Equal(t, Continue, decision)
+		})
+	})
+}
+
+func Test_Service_WithFastPath(t *testing.T) {
+	transaction := eventstore.Record{Event: eventspec.Event{EventID: "1", ClientID: "client-1", Type: string(eventspec.Transaction)}, Priority: eventspec.PriorityCritical}
+	alert := eventstore.Record{Event: eventspec.Event{EventID: "2", ClientID: "client-1", Type: string(eventspec.MonitoringAlert)}, Priority: eventspec.PriorityCritical}
+
+	t.Run("when critical events are rerouted to the fast path", func(t *testing.T) {
+		store := &mockStore{}
+		transactions := &mockStore{}
+		var published []string
+		publish := ProcessorFunc(func(ctx context.Context, record eventstore.Record) error {
+			published = append(published, record.EventID)
+			return nil
+		})
+		service := NewService(store, WithStages(RouteCritical()), WithFastPath(publish), WithRoute(eventspec.Transaction, PersistTo(transactions)))
+		transactions.On("Persist", mock.Anything, transaction).Return(nil)
+		store.On("Persist", mock.Anything, alert).Return(nil)
+
+		assert.NoError(t, service.Process(context.Background(), transaction))
+		assert.NoError(t, service.Process(context.Background(), alert))
+
+		t.Run("should persist them through the route of their type", func(t *testing.T) {
+			transactions.AssertExpectations(t)
+			store.AssertExpectations(t)
+			store.AssertNotCalled(t, "Persist", mock.Anything, transaction)
+		})
+
+		t.Run("then publish them", func(t *testing.T) {
+			assert.Equal(t, []string{"1", "2"}, published)
+		})
+	})
+
+	t.Run("when persisting fails", func(t *testing.T) {
+		store := &mockStore{}
+		publish := ProcessorFunc(func(ctx context.Context, record eventstore.Record) error {
+			t.Error("should not publish events that were not persisted")
+			return nil
+		})
+		service := NewService(store, WithStages(RouteCritical()), WithFastPath(publish))
+		store.On("Persist", mock.Anything, alert).Return(assert.AnError)
+
+		err := service.Process(context.Background(), alert)
+
+		t.Run("should return the error without publishing", func(t *testing.T) {
+			assert.ErrorIs(t, err, assert.AnError)
+		})
+	})
+}
@@ -0,0 +1,65 @@
+package eventprocessor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nivedita-verma/event-processor/internal/pkg/eventstore"
+	"github.com/nivedita-verma/event-processor/internal/pkg/failure"
+	"github.com/nivedita-verma/event-processor/internal/pkg/quota"
+	"github.com/nivedita-verma/event-processor/pkg/eventspec"
+)
+
+// QuotaMode is what happens to events over their client's quota.
+type QuotaMode string
+
+const (
+	// QuotaModeRetry fails the event with a retryable error, delaying it until
+	// SQS redelivers it.
+	QuotaModeRetry QuotaMode = "retry"
+	// QuotaModeQuarantine rejects the event with QUOTA_EXCEEDED.
+	QuotaModeQuarantine QuotaMode = "quarantine"
+	// QuotaModeFlag accepts the event, tagged with QuotaExceededTag.
+	QuotaModeFlag QuotaMode = "flag"
+)
+
+// QuotaExceededTag tags events accepted over quota with QuotaModeFlag.
+const QuotaExceededTag = "quota-exceeded"
+
+// Quota is the quota applied to every client.
+type Quota struct {
+	Limit quota.Limit
+	// PerType gives each client a bucket per event type rather than one for
+	// all its events.
+	PerType bool
+	Mode    QuotaMode
+}
+
+// EnforceQuota takes a token from the bucket of the event's client for every
+// event, handling events over quota as set by the quota's mode.
+func EnforceQuota(limiter quota.Api, q Quota) Stage {
+	return func(ctx context.Context, record *eventstore.Record) (Decision, error) {
+		key := record.ClientID
+		if q.PerType {
+			key += "#" + record.Type
+		}
+		taken, err := limiter.Take(ctx, key, q.Limit)
+		if err != nil || taken {
+			return Continue, err
+		}
+
+		switch q.Mode {
+		case QuotaModeFlag:
+			record.Tags = append(record.Tags, QuotaExceededTag)
+			return Continue, nil
+		case QuotaModeQuarantine:
+			return Continue, failure.Permanent(eventspec.ValidationErrors{{
+				Code:    eventspec.CodeQuotaExceeded,
+				Pointer: "/clientId",
+				Message: fmt.Sprintf("client %s is over its quota", record.ClientID),
+			}})
+		default:
+			return Continue, fmt.Errorf("client %s: %w", record.ClientID, quota.ErrExceeded)
+		}
+	}
+}
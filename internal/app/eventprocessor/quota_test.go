@@ -0,0 +1,99 @@
+package eventprocessor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nivedita-verma/event-processor/internal/pkg/eventstore"
+	"github.com/nivedita-verma/event-processor/internal/pkg/failure"
+	"github.com/nivedita-verma/event-processor/internal/pkg/quota"
+	"github.com/nivedita-verma/event-processor/pkg/eventspec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockLimiter struct {
+	mock.Mock
+}
+
+func (m *mockLimiter) Take(ctx context.Context, key string, limit quota.Limit) (bool, error) {
+	args := m.Called(ctx, key, limit)
+	return args.Bool(0), args.Error(1)
+}
+
+func Test_EnforceQuota(t *testing.T) {
+	limit := quota.Limit{Rate: 1, Burst: 1}
+	newRecord := func() *eventstore.Record {
+		return &eventstore.Record{Event: eventspec.Event{EventID: "1", ClientID: "client-1", Type: "transaction"}}
+	}
+
+	t.Run("when the client is within its quota", func(t *testing.T) {
+		limiter := &mockLimiter{}
+		limiter.On("Take", mock.Anything, "client-1", limit).Return(true, nil)
+
+		decision, err := EnforceQuota(limiter, Quota{Limit: limit, Mode: QuotaModeQuarantine})(context.Background(), newRecord())
+
+		t.Run("should continue", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Equal(t, Continue, decision)
+		})
+	})
+
+	t.Run("when the quota is per type", func(t *testing.T) {
+		limiter := &mockLimiter{}
+		limiter.On("Take", mock.Anything, "client-1#transaction", limit).Return(true, nil)
+
+		_, err := EnforceQuota(limiter, Quota{Limit: limit, PerType: true})(context.Background(), newRecord())
+
+		t.Run("should take from the bucket of the client's type", func(t *testing.T) {
+			assert.NoError(t, err)
+			limiter.AssertExpectations(t)
+		})
+	})
+
+	t.Run("when the client is over its quota", func(t *testing.T) {
+		limiter := &mockLimiter{}
+		limiter.On("Take", mock.Anything, mock.Anything, mock.Anything).Return(false, nil)
+
+		t.Run("with the retry mode", func(t *testing.T) {
+			_, err := EnforceQuota(limiter, Quota{Limit: limit, Mode: QuotaModeRetry})(context.Background(), newRecord())
+
+			t.Run("should return a retryable error", func(t *testing.T) {
+				assert.ErrorIs(t, err, quota.ErrExceeded)
+				assert.True(t, failure.IsRetryable(err))
+			})
+		})
+
+		t.Run("with the quarantine mode", func(t *testing.T) {
+			_, err := EnforceQuota(limiter, Quota{Limit: limit, Mode: QuotaModeQuarantine})(context.Background(), newRecord())
+
+			t.Run("should reject the event as over quota", func(t *testing.T) {
+				assert.True(t, failure.IsPermanent(err))
+				assert.Equal(t, eventspec.ValidationErrors{{Code: eventspec.CodeQuotaExceeded, Pointer: "/clientId", Message: "client client-1 is over its quota"}}, eventspec.AsValidationErrors(err))
+			})
+		})
+
+		t.Run("with the flag mode", func(t *testing.T) {
+			record := newRecord()
+
+			decision, err := EnforceQuota(limiter, Quota{Limit: limit, Mode: QuotaModeFlag})(context.Background(), record)
+
+			t.Run("should accept the event with a tag", func(t *testing.T) {
+				assert.NoError(t, err)
+				assert.Equal(t, Continue, decision)
+				assert.Equal(t, []string{QuotaExceededTag}, record.Tags)
+			})
+		})
+	})
+
+	t.Run("when the limiter returns an error", func(t *testing.T) {
+		limiter := &mockLimiter{}
+		limiter.On("Take", mock.Anything, mock.Anything, mock.Anything).Return(false, assert.AnError)
+
+		_, err := EnforceQuota(limiter, Quota{Limit: limit, Mode: QuotaModeQuarantine})(context.Background(), newRecord())
+
+		t.Run("should return the error", func(t *testing.T) {
+			assert.ErrorIs(t, err, assert.AnError)
+		})
+	})
+}
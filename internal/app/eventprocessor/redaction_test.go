@@ -0,0 +1,102 @@
+package eventprocessor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/nivedita-verma/event-processor/internal/pkg/eventstore"
+	"github.com/nivedita-verma/event-processor/internal/pkg/failure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// sensitiveEvent is a message of the corpus together with the values in it
+// that must never be logged.
+type sensitiveEvent struct {
+	Name      string   `json:"name"`
+	Body      string   `json:"body"`
+	Sensitive []string `json:"sensitive"`
+}
+
+// acceptingDynamoDB accepts every write, so events run through the whole path.
+type acceptingDynamoDB struct{}
+
+func (acceptingDynamoDB) PutItem(context.Context, *dynamodb.PutItemInput, ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (acceptingDynamoDB) TransactWriteItems(context.Context, *dynamodb.TransactWriteItemsInput, ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
+
+func (acceptingDynamoDB) GetItem(context.Context, *dynamodb.GetItemInput, ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	return &dynamodb.GetItemOutput{}, nil
+}
+
+func (acceptingDynamoDB) Query(context.Context, *dynamodb.QueryInput, ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	return &dynamodb.QueryOutput{}, nil
+}
+
+func Test_Handler_RedactsLogs(t *testing.T) {
+	content, err := os.ReadFile("testdata/sensitive_events.json")
+	assert.NoError(t, err)
+	var corpus []sensitiveEvent
+	assert.NoError(t, json.Unmarshal(content, &corpus))
+
+	for _, c := range corpus {
+		t.Run("when handling "+c.Name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.DebugLevel)
+			logger := zap.New(core).Sugar()
+			store := eventstore.NewDynamoDBStore(acceptingDynamoDB{}, "testTable", logger)
+			handler := NewHandler(logger, NewService(store, WithStages(RecordMetrics(logger))), newSchemaRegistry(t))
+
+			_, err := handler.HandleSQSEvent(context.Background(), createSQSEvent([]string{c.Body}))
+
+			t.Run("should never log its sensitive values", func(t *testing.T) {
+				assert.NoError(t, err)
+				assert.NotEmpty(t, logs.All())
+				for _, entry := range logs.All() {
+					logged := entry.Message + fmt.Sprint(entry.ContextMap())
+					for _, value := range c.Sensitive {
+						assert.NotContains(t, logged, value)
+					}
+				}
+			})
+		})
+	}
+}
+
+func Test_Handler_RedactsQuarantine(t *testing.T) {
+	content, err := os.ReadFile("testdata/sensitive_events.json")
+	assert.NoError(t, err)
+	var corpus []sensitiveEvent
+	assert.NoError(t, json.Unmarshal(content, &corpus))
+
+	for _, c := range corpus {
+		t.Run("when quarantining "+c.Name, func(t *testing.T) {
+			service := &mockService{}
+			service.On("Process", mock.Anything, mock.Anything).Return(failure.Permanent(assert.AnError))
+			quarantine := eventstore.NewMemoryQuarantine()
+			handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t), WithQuarantine(quarantine))
+
+			_, err := handler.HandleSQSEvent(context.Background(), createSQSEvent([]string{c.Body}))
+
+			t.Run("should never store its sensitive values in clear text", func(t *testing.T) {
+				assert.NoError(t, err)
+				if assert.Len(t, quarantine.Messages(), 1) {
+					message := quarantine.Messages()[0]
+					for _, value := range c.Sensitive {
+						assert.NotContains(t, message.Body+message.Reason, value)
+					}
+				}
+			})
+		})
+	}
+}
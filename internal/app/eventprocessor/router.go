@@ -0,0 +1,83 @@
+package eventprocessor
+
+import (
+	"context"
+	"errors"
+
+	"github.com/nivedita-verma/event-processor/internal/pkg/eventstore"
+	"github.com/nivedita-verma/event-processor/pkg/eventspec"
+)
+
+// Processor processes a validated event. Errors that will not succeed on retry
+// are marked with failure.Permanent; all other errors are retried.
+type Processor interface {
+	Process(context.Context, eventstore.Record) error
+}
+
+// ProcessorFunc adapts a function to a Processor.
+type ProcessorFunc func(context.Context, eventstore.Record) error
+
+func (f ProcessorFunc) Process(ctx context.Context, record eventstore.Record) error {
+	return f(ctx, record)
+}
+
+// Chain runs processors in order, stopping at the first error.
+func Chain(processors ...Processor) Processor {
+	if len(processors) == 1 {
+		return processors[0]
+	}
+	return ProcessorFunc(func(ctx context.Context, record eventstore.Record) error {
+		for _, processor := range processors {
+			if err := processor.Process(ctx, record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// PersistTo returns a processor that persists events to store. A duplicate
+// means an earlier delivery of the message was already stored, so it is
+// treated as success.
+func PersistTo(store eventstore.Api) Processor {
+	return ProcessorFunc(func(ctx context.Context, record eventstore.Record) error {
+		if err := store.Persist(ctx, record); err != nil && !errors.Is(err, eventstore.ErrDuplicateEvent) {
+			return err
+		}
+		return nil
+	})
+}
+
+// Router dispatches events to the processor chain registered for their type,
+// falling back to a default route for types without one.
+type Router struct {
+	routes   map[eventspec.EventType]Processor
+	fallback Processor
+}
+
+func NewRouter(fallback Processor) *Router {
+	return &Router{
+		routes:   make(map[eventspec.EventType]Processor),
+		fallback: fallback,
+	}
+}
+
+// Handle registers the processors run, in order, for events of eventType,
+// replacing any previously registered.
+func (r *Router) Handle(eventType eventspec.EventType, processors ...Processor) {
+	r.routes[eventType] = Chain(processors...)
+}
+
+func (r *Router) Process(ctx context.Context, record eventstore.Record) error {
+	processor, _ := r.route(eventspec.EventType(record.Type))
+	return processor.Process(ctx, record)
+}
+
+// route returns the processor for the given route, reporting whether it is a
+// route of its own rather than the default.
+func (r *Router) route(route eventspec.EventType) (Processor, bool) {
+	if processor, ok := r.routes[route]; ok {
+		return processor, true
+	}
+	return r.fallback, false
+}
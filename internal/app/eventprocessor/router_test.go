@@ -0,0 +1,89 @@
+package eventprocessor
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/nivedita-verma/event-processor/internal/pkg/eventstore"
+	"github.com/nivedita-verma/event-processor/pkg/eventspec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// recordingProcessor records the events it processes under its name.
+func recordingProcessor(name string, calls *[]string, err error) Processor {
+	return ProcessorFunc(func(ctx context.Context, record eventstore.Record) error {
+		*calls = append(*calls, name+":"+record.EventID)
+		return err
+	})
+}
+
+func Test_Chain(t *testing.T) {
+	record := eventstore.Record{Event: eventspec.Event{EventID: "1"}}
+
+	t.Run("when every processor succeeds", func(t *testing.T) {
+		var calls []string
+		err := Chain(recordingProcessor("a", &calls, nil), recordingProcessor("b", &calls, nil)).Process(context.Background(), record)
+
+		t.Run("should run the processors in order", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"a:1", "b:1"}, calls)
+		})
+	})
+
+	t.Run("when a processor fails", func(t *testing.T) {
+		var calls []string
+		err := Chain(recordingProcessor("a", &calls, assert.AnError), recordingProcessor("b", &calls, nil)).Process(context.Background(), record)
+
+		t.Run("should stop at the failing processor", func(t *testing.T) {
+			assert.ErrorIs(t, err, assert.AnError)
+			assert.Equal(t, []string{"a:1"}, calls)
+		})
+	})
+}
+
+func Test_PersistTo(t *testing.T) {
+	record := eventstore.Record{Event: eventspec.Event{EventID: "1", ClientID: "client-1"}}
+
+	t.Run("when the store reports a duplicate event", func(t *testing.T) {
+		store := &mockStore{}
+		store.On("Persist", mock.Anything, record).Return(fmt.Errorf("event 1: %w", eventstore.ErrDuplicateEvent))
+
+		err := PersistTo(store).Process(context.Background(), record)
+
+		t.Run("should complete without error", func(t *testing.T) {
+			assert.NoError(t, err)
+		})
+	})
+
+	t.Run("when the store fails", func(t *testing.T) {
+		store := &mockStore{}
+		store.On("Persist", mock.Anything, record).Return(assert.AnError)
+
+		err := PersistTo(store).Process(context.Background(), record)
+
+		t.Run("should return the error", func(t *testing.T) {
+			assert.ErrorIs(t, err, assert.AnError)
+		})
+	})
+}
+
+func Test_Router_Process(t *testing.T) {
+	var calls []string
+	router := NewRouter(recordingProcessor("default", &calls, nil))
+	router.Handle(eventspec.Transaction, recordingProcessor("enrich", &calls, nil), recordingProcessor("transactions", &calls, nil))
+	router.Handle(eventspec.MonitoringAlert, recordingProcessor("alerts", &calls, nil))
+
+	for _, record := range []eventstore.Record{
+		{Event: eventspec.Event{EventID: "1", Type: string(eventspec.Transaction)}},
+		{Event: eventspec.Event{EventID: "2", Type: string(eventspec.Notification)}},
+		{Event: eventspec.Event{EventID: "3", Type: string(eventspec.MonitoringAlert)}},
+	} {
+		assert.NoError(t, router.Process(context.Background(), record))
+	}
+
+	t.Run("should run the chain registered for each type and the default route otherwise", func(t *testing.T) {
+		assert.Equal(t, []string{"enrich:1", "transactions:1", "default:2", "alerts:3"}, calls)
+	})
+}
@@ -0,0 +1,34 @@
+package eventprocessor
+
+import (
+	"context"
+	"slices"
+
+	"github.com/nivedita-verma/event-processor/internal/pkg/eventstore"
+	"github.com/nivedita-verma/event-processor/internal/pkg/rules"
+	"github.com/nivedita-verma/event-processor/pkg/eventspec"
+)
+
+// ApplyRules applies the actions of the rules matching each event, in order.
+// A drop rule stops evaluation and drops the event; for route rules the last
+// one to match wins, as with the reroutes of other stages.
+func ApplyRules(set *rules.RuleSet) Stage {
+	return func(ctx context.Context, record *eventstore.Record) (Decision, error) {
+		decision := Continue
+		for _, rule := range set.Match(record.Event) {
+			switch rule.Action {
+			case rules.ActionDrop:
+				return Drop, nil
+			case rules.ActionTag:
+				if !slices.Contains(record.Tags, rule.Value) {
+					record.Tags = append(record.Tags, rule.Value)
+				}
+			case rules.ActionSetPriority:
+				record.Priority = eventspec.Priority(rule.Value)
+			case rules.ActionRoute:
+				decision = Reroute(eventspec.EventType(rule.Value))
+			}
+		}
+		return decision, nil
+	}
+}
@@ -0,0 +1,77 @@
+package eventprocessor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nivedita-verma/event-processor/internal/pkg/eventstore"
+	"github.com/nivedita-verma/event-processor/internal/pkg/rules"
+	"github.com/nivedita-verma/event-processor/pkg/eventspec"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ApplyRules(t *testing.T) {
+	set, err := rules.NewRuleSet([]rules.Rule{
+		{Name: "test-clients", When: `clientId == "test"`, Action: rules.ActionDrop},
+		{Name: "large", When: `type == "transaction" && data.amount > 10000`, Action: rules.ActionSetPriority, Value: "high"},
+		{Name: "large-tag", When: `data.amount > 10000`, Action: rules.ActionTag, Value: "large"},
+		{Name: "gbp", When: `data.currency == "GBP"`, Action: rules.ActionTag, Value: "gbp"},
+		{Name: "review", When: `data.amount > 50000`, Action: rules.ActionRoute, Value: "review"},
+		{Name: "audit", When: `data.amount > 90000`, Action: rules.ActionRoute, Value: "audit"},
+	})
+	assert.NoError(t, err)
+	stage := ApplyRules(set)
+
+	t.Run("when no rule matches", func(t *testing.T) {
+		record := &eventstore.Record{Event: eventspec.Event{ClientID: "client-1", Type: "notification"}}
+
+		decision, err := stage(context.Background(), record)
+
+		t.Run("should continue without changing the record", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Equal(t, Continue, decision)
+			assert.Empty(t, record.Tags)
+			assert.Empty(t, record.Priority)
+		})
+	})
+
+	t.Run("when rules tag and prioritise the event", func(t *testing.T) {
+		record := &eventstore.Record{
+			Event: eventspec.Event{ClientID: "client-1", Type: "transaction", Data: map[string]interface{}{"amount": 20000.0, "currency": "GBP"}},
+			Tags:  []string{"gbp"},
+		}
+
+		decision, err := stage(context.Background(), record)
+
+		t.Run("should apply each action once and continue", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Equal(t, Continue, decision)
+			assert.Equal(t, []string{"gbp", "large"}, record.Tags)
+			assert.Equal(t, eventspec.PriorityHigh, record.Priority)
+		})
+	})
+
+	t.Run("when several route rules match", func(t *testing.T) {
+		record := &eventstore.Record{Event: eventspec.Event{ClientID: "client-1", Type: "transaction", Data: map[string]interface{}{"amount": 95000.0}}}
+
+		decision, err := stage(context.Background(), record)
+
+		t.Run("should reroute to the last one", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Equal(t, Reroute("audit"), decision)
+		})
+	})
+
+	t.Run("when a drop rule matches", func(t *testing.T) {
+		record := &eventstore.Record{Event: eventspec.Event{ClientID: "test", Type: "transaction", Data: map[string]interface{}{"amount": 95000.0}}}
+
+		decision, err := stage(context.Background(), record)
+
+		t.Run("should drop the event without applying later rules", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Equal(t, Drop, decision)
+			assert.Empty(t, record.Tags)
+			assert.Empty(t, record.Priority)
+		})
+	})
+}
@@ -2,24 +2,144 @@ package eventprocessor
 
 import (
 	"context"
+	"errors"
 
 	"github.com/nivedita-verma/event-processor/internal/pkg/eventstore"
 	"github.com/nivedita-verma/event-processor/pkg/eventspec"
 )
 
+// Service runs events through the pipeline and then triages them by type.
+// Events of types without a route of their own are persisted to the default
+// store.
 type Service struct {
-	store eventstore.Api
+	store    eventstore.Api
+	router   *Router
+	pipeline Pipeline
 }
 
-func NewService(store eventstore.Api) *Service {
-	return &Service{
-		store: store,
+type ServiceOption func(*Service)
+
+// WithRoute processes events of eventType with the given processors, in
+// order, instead of persisting them to the default store. Use PersistTo to
+// send them to a different store.
+func WithRoute(eventType eventspec.EventType, processors ...Processor) ServiceOption {
+	return func(s *Service) {
+		s.router.Handle(eventType, processors...)
+	}
+}
+
+// WithStages runs the given stages, in order, over every event before it is
+// routed.
+func WithStages(stages ...Stage) ServiceOption {
+	return func(s *Service) {
+		s.pipeline = append(s.pipeline, stages...)
+	}
+}
+
+func NewService(store eventstore.Api, opts ...ServiceOption) *Service {
+	service := &Service{
+		store:  store,
+		router: NewRouter(PersistTo(store)),
 	}
+	for _, opt := range opts {
+		opt(service)
+	}
+	return service
+}
+
+func (s *Service) Process(ctx context.Context, record eventstore.Record) error {
+	processor, _, err := s.triage(ctx, &record)
+	if err != nil || processor == nil {
+		return err
+	}
+	return processor.Process(ctx, record)
 }
 
-func (s *Service) Process(ctx context.Context, event eventspec.Event) error {
-	// Add any business logic or transformations here
+// triage runs the pipeline over the record and returns the processor of the
+// route it takes, reporting whether that is a route of its own. The processor
+// is nil when the record was dropped.
+func (s *Service) triage(ctx context.Context, record *eventstore.Record) (Processor, bool, error) {
+	decision, err := s.pipeline.Run(ctx, record)
+	if err != nil || decision.Dropped() {
+		return nil, false, err
+	}
+	route, ok := decision.Route()
+	if !ok {
+		route = eventspec.EventType(record.Type)
+	}
+	processor, routed := s.router.route(route)
+	return processor, routed, nil
+}
 
-	// Persist the event
-	return s.store.Persist(ctx, event)
+// ProcessBatch runs the pipeline over every record, then persists records on
+// the default route in as few round trips as the store allows and processes
+// records with a route of their own one by one. It returns a
+// *eventstore.BatchError for the records that failed. Duplicates are treated
+// as success, as in Process.
+func (s *Service) ProcessBatch(ctx context.Context, records []eventstore.Record) error {
+	itemErrors := make(map[int]error)
+	var batch []eventstore.Record
+	var batchIndexes []int
+	for i, record := range records {
+		processor, routed, err := s.triage(ctx, &record)
+		if err != nil {
+			itemErrors[i] = err
+			continue
+		}
+		if processor == nil {
+			continue
+		}
+		if routed {
+			if err := processor.Process(ctx, record); err != nil {
+				itemErrors[i] = err
+			}
+			continue
+		}
+		batch = append(batch, record)
+		batchIndexes = append(batchIndexes, i)
+	}
+
+	if len(batch) > 0 {
+		err := s.persistBatch(ctx, batch)
+		var batchErr *eventstore.BatchError
+		if errors.As(err, &batchErr) {
+			for j, itemErr := range batchErr.Errors {
+				itemErrors[batchIndexes[j]] = itemErr
+			}
+		} else if err != nil {
+			for _, i := range batchIndexes {
+				itemErrors[i] = err
+			}
+		}
+	}
+
+	for index, itemErr := range itemErrors {
+		if errors.Is(itemErr, eventstore.ErrDuplicateEvent) {
+			delete(itemErrors, index)
+		}
+	}
+	if len(itemErrors) == 0 {
+		return nil
+	}
+	return &eventstore.BatchError{Errors: itemErrors}
+}
+
+func (s *Service) persistBatch(ctx context.Context, records []eventstore.Record) error {
+	if batchStore, ok := s.store.(eventstore.BatchApi); ok {
+		return batchStore.PersistBatch(ctx, records)
+	}
+	return s.persistEach(ctx, records)
+}
+
+func (s *Service) persistEach(ctx context.Context, records []eventstore.Record) error {
+	itemErrors := make(map[int]error)
+	for i, record := range records {
+		if err := s.store.Persist(ctx, record); err != nil {
+			itemErrors[i] = err
+		}
+	}
+	if len(itemErrors) > 0 {
+		return &eventstore.BatchError{Errors: itemErrors}
+	}
+	return nil
 }
@@ -2,8 +2,10 @@ package eventprocessor
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
+	"github.com/nivedita-verma/event-processor/internal/pkg/eventstore"
 	"github.com/nivedita-verma/event-processor/pkg/eventspec"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -19,8 +21,8 @@ type mockStore struct {
 	mock.Mock
 }
 
-func (m *mockStore) Persist(ctx context.Context, event eventspec.Event) error {
-	args := m.Called(ctx, event)
+func (m *mockStore) Persist(ctx context.Context, record eventstore.Record) error {
+	args := m.Called(ctx, record)
 	if args.Get(0) == nil {
 		return nil
 	}
@@ -31,25 +33,40 @@ func Test_Service_Process(t *testing.T) {
 	t.Run("when store.Persist returns an error", func(t *testing.T) {
 		store := &mockStore{}
 		service := NewService(store)
-		event := eventspec.Event{EventID: "1", ClientID: "client-1", Type: "TestEvent", Data: map[string]interface{}{"key": "value"}}
+		record := eventstore.Record{Event: eventspec.Event{EventID: "1", ClientID: "client-1", Type: "TestEvent", Data: map[string]interface{}{"key": "value"}}}
 
-		store.On("Persist", mock.Anything, event).Return(assert.AnError)
+		store.On("Persist", mock.Anything, record).Return(assert.AnError)
 
-		err := service.Process(context.Background(), event)
+		err := service.Process(context.Background(), record)
 		t.Run("should return the Persist error", func(t *testing.T) {
 			assert.Error(t, err)
 			store.AssertExpectations(t)
 		})
 	})
 
+	t.Run("when store.Persist reports a duplicate event", func(t *testing.T) {
+		store := &mockStore{}
+		service := NewService(store)
+		record := eventstore.Record{Event: eventspec.Event{EventID: "1", ClientID: "client-1", Type: "TestEvent", Data: map[string]interface{}{"key": "value"}}}
+
+		store.On("Persist", mock.Anything, record).Return(fmt.Errorf("event 1: %w", eventstore.ErrDuplicateEvent))
+
+		err := service.Process(context.Background(), record)
+
+		t.Run("should treat it as success", func(t *testing.T) {
+			assert.NoError(t, err)
+			store.AssertExpectations(t)
+		})
+	})
+
 	t.Run("when store.Persist is successful", func(t *testing.T) {
 		store := &mockStore{}
 		service := NewService(store)
-		event := eventspec.Event{EventID: "1", ClientID: "client-1", Type: "TestEvent", Data: map[string]interface{}{"key": "value"}}
+		record := eventstore.Record{Event: eventspec.Event{EventID: "1", ClientID: "client-1", Type: "TestEvent", Data: map[string]interface{}{"key": "value"}}}
 
-		store.On("Persist", mock.Anything, event).Return(nil)
+		store.On("Persist", mock.Anything, record).Return(nil)
 
-		err := service.Process(context.Background(), event)
+		err := service.Process(context.Background(), record)
 
 		t.Run("should complete without error", func(t *testing.T) {
 			assert.NoError(t, err)
@@ -57,3 +74,183 @@ func Test_Service_Process(t *testing.T) {
 		})
 	})
 }
+
+type mockBatchStore struct {
+	mockStore
+}
+
+func (m *mockBatchStore) PersistBatch(ctx context.Context, records []eventstore.Record) error {
+	args := m.Called(ctx, records)
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Error(0)
+}
+
+func Test_Service_ProcessBatch(t *testing.T) {
+	records := []eventstore.Record{
+		{Event: eventspec.Event{EventID: "1", ClientID: "client-1", Type: "TestEvent", Data: map[string]interface{}{"key": "value"}}},
+		{Event: eventspec.Event{EventID: "2", ClientID: "client-1", Type: "TestEvent", Data: map[string]interface{}{"key": "value"}}},
+	}
+
+	t.Run("when the store supports batch writes", func(t *testing.T) {
+		store := &mockBatchStore{}
+		service := NewService(store)
+		store.On("PersistBatch", mock.Anything, records).Return(nil)
+
+		err := service.ProcessBatch(context.Background(), records)
+
+		t.Run("should persist the events in one call", func(t *testing.T) {
+			assert.NoError(t, err)
+			store.AssertExpectations(t)
+			store.AssertNotCalled(t, "Persist", mock.Anything, mock.Anything)
+		})
+	})
+
+	t.Run("when the store reports duplicates and failures", func(t *testing.T) {
+		store := &mockBatchStore{}
+		service := NewService(store)
+		store.On("PersistBatch", mock.Anything, records).Return(&eventstore.BatchError{Errors: map[int]error{
+			0: eventstore.ErrDuplicateEvent,
+			1: assert.AnError,
+		}})
+
+		err := service.ProcessBatch(context.Background(), records)
+
+		t.Run("should report only the failures", func(t *testing.T) {
+			var batchErr *eventstore.BatchError
+			if assert.ErrorAs(t, err, &batchErr) {
+				assert.Equal(t, map[int]error{1: assert.AnError}, batchErr.Errors)
+			}
+		})
+	})
+
+	t.Run("when the store reports only duplicates", func(t *testing.T) {
+		store := &mockBatchStore{}
+		service := NewService(store)
+		store.On("PersistBatch", mock.Anything, records).Return(&eventstore.BatchError{Errors: map[int]error{0: eventstore.ErrDuplicateEvent}})
+
+		err := service.ProcessBatch(context.Background(), records)
+
+		t.Run("should complete without error", func(t *testing.T) {
+			assert.NoError(t, err)
+		})
+	})
+
+	t.Run("when the store does not support batch writes", func(t *testing.T) {
+		store := &mockStore{}
+		service := NewService(store)
+		store.On("Persist", mock.Anything, records[0]).Return(nil)
+		store.On("Persist", mock.Anything, records[1]).Return(assert.AnError)
+
+		err := service.ProcessBatch(context.Background(), records)
+
+		t.Run("should persist each event and report the failures by index", func(t *testing.T) {
+			store.AssertExpectations(t)
+			var batchErr *eventstore.BatchError
+			if assert.ErrorAs(t, err, &batchErr) {
+				assert.Equal(t, map[int]error{1: assert.AnError}, batchErr.Errors)
+			}
+		})
+	})
+}
+
+func Test_Service_WithRoute(t *testing.T) {
+	transaction := eventstore.Record{Event: eventspec.Event{EventID: "1", ClientID: "client-1", Type: string(eventspec.Transaction)}}
+	notification := eventstore.Record{Event: eventspec.Event{EventID: "2", ClientID: "client-1", Type: string(eventspec.Notification)}}
+
+	t.Run("when processing events one at a time", func(t *testing.T) {
+		store := &mockStore{}
+		transactions := &mockStore{}
+		service := NewService(store, WithRoute(eventspec.Transaction, PersistTo(transactions)))
+		store.On("Persist", mock.Anything, notification).Return(nil)
+		transactions.On("Persist", mock.Anything, transaction).Return(nil)
+
+		assert.NoError(t, service.Process(context.Background(), transaction))
+		assert.NoError(t, service.Process(context.Background(), notification))
+
+		t.Run("should persist routed events to their own destination", func(t *testing.T) {
+			store.AssertExpectations(t)
+			transactions.AssertExpectations(t)
+			store.AssertNotCalled(t, "Persist", mock.Anything, transaction)
+		})
+	})
+
+	t.Run("when processing a batch", func(t *testing.T) {
+		store := &mockBatchStore{}
+		transactions := &mockStore{}
+		service := NewService(store, WithRoute(eventspec.Transaction, PersistTo(transactions)))
+		store.On("PersistBatch", mock.Anything, []eventstore.Record{notification}).Return(assert.AnError)
+		transactions.On("Persist", mock.Anything, transaction).Return(nil)
+
+		err := service.ProcessBatch(context.Background(), []eventstore.Record{transaction, notification})
+
+		t.Run("should batch only the events on the default route", func(t *testing.T) {
+			store.AssertExpectations(t)
+			transactions.AssertExpectations(t)
+		})
+
+		t.Run("should report failures by their index in the batch", func(t *testing.T) {
+			var batchErr *eventstore.BatchError
+			if assert.ErrorAs(t, err, &batchErr) {
+				assert.Equal(t, map[int]error{1: assert.AnError}, batchErr.Errors)
+			}
+		})
+	})
+}
+
+func Test_Service_WithStages(t *testing.T) {
+	dropped := eventstore.Record{Event: eventspec.Event{EventID: "1", ClientID: "client-1", Type: string(eventspec.Notification)}}
+	rerouted := eventstore.Record{Event: eventspec.Event{EventID: "2", ClientID: "client-1", Type: string(eventspec.Notification)}}
+	failed := eventstore.Record{Event: eventspec.Event{EventID: "3", ClientID: "client-1", Type: string(eventspec.Notification)}}
+	stage := func(ctx context.Context, record *eventstore.Record) (Decision, error) {
+		switch record.EventID {
+		case "1":
+			return Drop, nil
+		case "2":
+			return Reroute("review"), nil
+		case "3":
+			return Continue, assert.AnError
+		}
+		return Continue, nil
+	}
+
+	t.Run("when processing events one at a time", func(t *testing.T) {
+		store := &mockStore{}
+		review := &mockStore{}
+		service := NewService(store, WithStages(stage), WithRoute("review", PersistTo(review)))
+		review.On("Persist", mock.Anything, rerouted).Return(nil)
+
+		t.Run("should not persist dropped events", func(t *testing.T) {
+			assert.NoError(t, service.Process(context.Background(), dropped))
+			store.AssertNotCalled(t, "Persist", mock.Anything, mock.Anything)
+		})
+
+		t.Run("should send rerouted events to their new route", func(t *testing.T) {
+			assert.NoError(t, service.Process(context.Background(), rerouted))
+			review.AssertExpectations(t)
+		})
+
+		t.Run("should return stage errors", func(t *testing.T) {
+			assert.ErrorIs(t, service.Process(context.Background(), failed), assert.AnError)
+		})
+	})
+
+	t.Run("when processing a batch", func(t *testing.T) {
+		store := &mockBatchStore{}
+		review := &mockStore{}
+		service := NewService(store, WithStages(stage), WithRoute("review", PersistTo(review)))
+		review.On("Persist", mock.Anything, rerouted).Return(nil)
+
+		err := service.ProcessBatch(context.Background(), []eventstore.Record{dropped, rerouted, failed})
+
+		t.Run("should only report the failed stage", func(t *testing.T) {
+			var batchErr *eventstore.BatchError
+			if assert.ErrorAs(t, err, &batchErr) {
+				assert.Equal(t, map[int]error{2: assert.AnError}, batchErr.Errors)
+			}
+			review.AssertExpectations(t)
+			store.AssertNotCalled(t, "PersistBatch", mock.Anything, mock.Anything)
+		})
+	})
+}
@@ -0,0 +1,32 @@
+package eventprocessor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nivedita-verma/event-processor/internal/pkg/signing"
+	"github.com/nivedita-verma/event-processor/pkg/eventspec"
+)
+
+// checkSignature checks that the envelope body is signed with the key of the
+// event's client, returning the violation found. Only failing to read the key
+// is returned as an error.
+func checkSignature(ctx context.Context, keys signing.KeyProvider, envelope Envelope, clientID string) (eventspec.ValidationErrors, error) {
+	signature := envelope.Attributes[eventspec.SignatureAttribute]
+	if signature == "" {
+		return eventspec.ValidationErrors{{Code: eventspec.CodeMissingSignature, Message: "message is not signed"}}, nil
+	}
+	key, err := keys.Key(ctx, clientID)
+	if errors.Is(err, signing.ErrKeyNotFound) {
+		return eventspec.ValidationErrors{{Code: eventspec.CodeInvalidSignature, Pointer: "/clientId", Message: fmt.Sprintf("no signing key for client: %s", clientID)}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key of client %s: %w", clientID, err)
+	}
+	algorithm := eventspec.SignatureAlgorithm(envelope.Attributes[eventspec.SignatureAlgorithmAttribute])
+	if err := key.Verify([]byte(envelope.Body), algorithm, signature); err != nil {
+		return eventspec.ValidationErrors{{Code: eventspec.CodeInvalidSignature, Message: err.Error()}}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,80 @@
+package eventprocessor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/nivedita-verma/event-processor/internal/pkg/clientregistry"
+	"github.com/nivedita-verma/event-processor/internal/pkg/signing"
+	"github.com/nivedita-verma/event-processor/pkg/eventspec"
+	"github.com/stretchr/testify/assert"
+)
+
+// signedMessage returns a message carrying the body and the attributes given.
+func signedMessage(body string, attributes map[string]string) events.SQSMessage {
+	message := events.SQSMessage{MessageId: "msg-1", Body: body, MessageAttributes: map[string]events.SQSMessageAttribute{}}
+	for name, value := range attributes {
+		message.MessageAttributes[name] = events.SQSMessageAttribute{DataType: "String", StringValue: &value}
+	}
+	return message
+}
+
+func Test_checkSignature(t *testing.T) {
+	secret := []byte("client-1-secret")
+	keys := signing.NewRegistryKeyProvider(clientregistry.NewMemoryRegistry(
+		clientregistry.Client{ClientID: "client-1", SigningKey: &eventspec.VerificationKey{Algorithm: eventspec.SignatureHMACSHA256, Key: secret}},
+		clientregistry.Client{ClientID: "client-2"},
+	))
+	body := `{"eventId":"1","clientId":"client-1","type":"notification","data":{"message":"hello"}}`
+	cases := []struct {
+		name     string
+		envelope Envelope
+		clientID string
+		expected eventspec.ValidationErrors
+	}{
+		{
+			name:     "a message signed with the client's key",
+			envelope: Envelope{Body: body, Attributes: eventspec.NewHMACSigner(secret).Sign([]byte(body))},
+			clientID: "client-1",
+		},
+		{
+			name:     "an unsigned message",
+			envelope: Envelope{Body: body},
+			clientID: "client-1",
+			expected: eventspec.ValidationErrors{{Code: eventspec.CodeMissingSignature, Message: "message is not signed"}},
+		},
+		{
+			name:     "a message signed with another key",
+			envelope: Envelope{Body: body, Attributes: eventspec.NewHMACSigner([]byte("other-secret")).Sign([]byte(body))},
+			clientID: "client-1",
+			expected: eventspec.ValidationErrors{{Code: eventspec.CodeInvalidSignature, Message: "invalid signature: signature does not match the body"}},
+		},
+		{
+			name:     "a signed message of a client without a key",
+			envelope: Envelope{Body: body, Attributes: eventspec.NewHMACSigner(secret).Sign([]byte(body))},
+			clientID: "client-2",
+			expected: eventspec.ValidationErrors{{Code: eventspec.CodeInvalidSignature, Pointer: "/clientId", Message: "no signing key for client: client-2"}},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run("when checking "+c.name, func(t *testing.T) {
+			errs, err := checkSignature(context.Background(), keys, c.envelope, c.clientID)
+
+			t.Run("should return the expected violations", func(t *testing.T) {
+				assert.NoError(t, err)
+				assert.Equal(t, c.expected, errs)
+			})
+		})
+	}
+
+	t.Run("when the key cannot be read", func(t *testing.T) {
+		failing := signing.NewRegistryKeyProvider(&mockRegistry{err: assert.AnError})
+		_, err := checkSignature(context.Background(), failing, Envelope{Body: body, Attributes: eventspec.NewHMACSigner(secret).Sign([]byte(body))}, "client-1")
+
+		t.Run("should return the error", func(t *testing.T) {
+			assert.ErrorIs(t, err, assert.AnError)
+		})
+	})
+}
@@ -0,0 +1,55 @@
+package eventprocessor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// HandleSNSEvent validates and processes the events published to an SNS topic
+// the processor subscribes to. The message of a notification is the event, and
+// its message attributes are checked as those of an SQS message, so events can
+// be signed. Lambda is invoked asynchronously by SNS and cannot retry single
+// records, so an error is returned if any event is to be retried and the whole
+// invocation is retried; events already processed are treated as duplicates.
+func (h *Handler) HandleSNSEvent(ctx context.Context, snsEvent events.SNSEvent) error {
+	envelopes := make([]Envelope, len(snsEvent.Records))
+	for i, record := range snsEvent.Records {
+		envelopes[i] = snsEnvelope(record)
+	}
+	var retry []string
+	for _, result := range h.Ingest(ctx, envelopes) {
+		if result.Retry {
+			retry = append(retry, result.MessageID)
+		}
+	}
+	if len(retry) > 0 {
+		return fmt.Errorf("failed to process SNS messages %s", strings.Join(retry, ", "))
+	}
+	return nil
+}
+
+// snsEnvelope carries the event of a notification, with its string message
+// attributes and the time it was published as the time it was sent.
+func snsEnvelope(record events.SNSEventRecord) Envelope {
+	envelope := Envelope{
+		MessageID: record.SNS.MessageID,
+		Body:      record.SNS.Message,
+		Source:    "aws:sns",
+		SentAt:    record.SNS.Timestamp,
+	}
+	// SNS delivers message attributes to Lambda as {"Type": ..., "Value": ...}
+	for name, value := range record.SNS.MessageAttributes {
+		attribute, _ := value.(map[string]interface{})
+		stringValue, ok := attribute["Value"].(string)
+		if ok && attribute["Type"] == "String" && stringValue != "" {
+			if envelope.Attributes == nil {
+				envelope.Attributes = make(map[string]string)
+			}
+			envelope.Attributes[name] = stringValue
+		}
+	}
+	return envelope
+}
@@ -0,0 +1,114 @@
+package eventprocessor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/nivedita-verma/event-processor/internal/pkg/clientregistry"
+	"github.com/nivedita-verma/event-processor/internal/pkg/eventstore"
+	"github.com/nivedita-verma/event-processor/internal/pkg/signing"
+	"github.com/nivedita-verma/event-processor/pkg/eventspec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func newSNSRecord(messageID, message string, attributes map[string]string) events.SNSEventRecord {
+	record := events.SNSEventRecord{
+		EventSource: "aws:sns",
+		SNS: events.SNSEntity{
+			MessageID:         messageID,
+			TopicArn:          "arn:aws:sns:eu-west-1:123456789012:events",
+			Message:           message,
+			Timestamp:         time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			MessageAttributes: map[string]interface{}{},
+		},
+	}
+	for name, value := range attributes {
+		record.SNS.MessageAttributes[name] = map[string]interface{}{"Type": "String", "Value": value}
+	}
+	return record
+}
+
+func Test_Handler_HandleSNSEvent(t *testing.T) {
+	body := `{"eventId":"1","clientId":"client-1","type":"notification","data":{"message":"hello"}}`
+
+	t.Run("when the event is valid", func(t *testing.T) {
+		service := &mockService{}
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t))
+		service.On("Process", mock.Anything, withEventID("1")).Return(nil)
+
+		err := handler.HandleSNSEvent(context.Background(), events.SNSEvent{Records: []events.SNSEventRecord{
+			newSNSRecord("sns-1", body, nil),
+		}})
+
+		t.Run("then it should be processed with the notification's metadata", func(t *testing.T) {
+			assert.NoError(t, err)
+			record := service.Calls[0].Arguments.Get(1).(eventstore.Record)
+			assert.Equal(t, "sns-1", record.Metadata.MessageID)
+			assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), record.Metadata.SentAt)
+		})
+	})
+
+	t.Run("when the event fails with a retryable error", func(t *testing.T) {
+		service := &mockService{}
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t))
+		service.On("Process", mock.Anything, mock.Anything).Return(assert.AnError)
+
+		err := handler.HandleSNSEvent(context.Background(), events.SNSEvent{Records: []events.SNSEventRecord{
+			newSNSRecord("sns-1", body, nil),
+		}})
+
+		t.Run("then an error should be returned for Lambda to retry", func(t *testing.T) {
+			assert.EqualError(t, err, "failed to process SNS messages sns-1")
+		})
+	})
+
+	t.Run("when the event is invalid", func(t *testing.T) {
+		service := &mockService{}
+		quarantine := eventstore.NewMemoryQuarantine()
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t), WithQuarantine(quarantine))
+
+		err := handler.HandleSNSEvent(context.Background(), events.SNSEvent{Records: []events.SNSEventRecord{
+			newSNSRecord("sns-1", `{"eventId":"1","clientId":"client-1","type":"unsupported","data":{}}`, nil),
+		}})
+
+		t.Run("then it should be quarantined", func(t *testing.T) {
+			assert.NoError(t, err)
+			messages := quarantine.Messages()
+			if assert.Len(t, messages, 1) {
+				assert.Equal(t, "sns-1", messages[0].MessageID)
+				assert.Equal(t, "UNSUPPORTED_TYPE", messages[0].RejectionCode)
+			}
+			service.AssertNotCalled(t, "Process", mock.Anything, mock.Anything)
+		})
+	})
+
+	t.Run("when signatures are verified", func(t *testing.T) {
+		secret := []byte("client-1-secret")
+		keys := signing.NewRegistryKeyProvider(clientregistry.NewMemoryRegistry(
+			clientregistry.Client{ClientID: "client-1", SigningKey: &eventspec.VerificationKey{Algorithm: eventspec.SignatureHMACSHA256, Key: secret}},
+		))
+		service := &mockService{}
+		quarantine := eventstore.NewMemoryQuarantine()
+		handler := NewHandler(zap.NewNop().Sugar(), service, newSchemaRegistry(t), WithQuarantine(quarantine), WithSignatureVerification(keys))
+		service.On("Process", mock.Anything, mock.Anything).Return(nil)
+
+		err := handler.HandleSNSEvent(context.Background(), events.SNSEvent{Records: []events.SNSEventRecord{
+			newSNSRecord("sns-1", body, eventspec.NewHMACSigner(secret).Sign([]byte(body))),
+			newSNSRecord("sns-2", body, nil),
+		}})
+
+		t.Run("then signed events should be processed and unsigned events quarantined", func(t *testing.T) {
+			assert.NoError(t, err)
+			service.AssertNumberOfCalls(t, "Process", 1)
+			messages := quarantine.Messages()
+			if assert.Len(t, messages, 1) {
+				assert.Equal(t, "sns-2", messages[0].MessageID)
+				assert.Equal(t, "MISSING_SIGNATURE", messages[0].RejectionCode)
+			}
+		})
+	})
+}
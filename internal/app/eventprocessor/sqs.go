@@ -0,0 +1,52 @@
+package eventprocessor
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// HandleSQSEvent validates and processes the messages of an SQS batch,
+// reporting the messages to be redelivered in BatchItemFailures.
+func (h *Handler) HandleSQSEvent(ctx context.Context, sqsEvent events.SQSEvent) (events.SQSEventResponse, error) {
+	sqsEventResponse := events.SQSEventResponse{
+		BatchItemFailures: []events.SQSBatchItemFailure{},
+	}
+	envelopes := make([]Envelope, len(sqsEvent.Records))
+	for i, message := range sqsEvent.Records {
+		envelopes[i] = sqsEnvelope(message)
+	}
+	for _, result := range h.Ingest(ctx, envelopes) {
+		if result.Retry {
+			sqsEventResponse.BatchItemFailures = append(sqsEventResponse.BatchItemFailures, events.SQSBatchItemFailure{ItemIdentifier: result.MessageID})
+		}
+	}
+
+	return sqsEventResponse, nil
+}
+
+// sqsEnvelope carries the body and string message attributes of a message.
+func sqsEnvelope(message events.SQSMessage) Envelope {
+	envelope := Envelope{
+		MessageID: message.MessageId,
+		Body:      message.Body,
+		Source:    message.EventSource,
+	}
+	for name, attribute := range message.MessageAttributes {
+		if attribute.StringValue != nil && *attribute.StringValue != "" {
+			if envelope.Attributes == nil {
+				envelope.Attributes = make(map[string]string)
+			}
+			envelope.Attributes[name] = *attribute.StringValue
+		}
+	}
+	// ApproximateReceiveCount is the number of deliveries so far, and
+	// SentTimestamp the time the message was sent, in epoch milliseconds
+	envelope.ReceiveCount, _ = strconv.Atoi(message.Attributes["ApproximateReceiveCount"])
+	if sentAt, err := strconv.ParseInt(message.Attributes["SentTimestamp"], 10, 64); err == nil {
+		envelope.SentAt = time.UnixMilli(sentAt).UTC()
+	}
+	return envelope
+}
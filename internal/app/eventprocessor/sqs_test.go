@@ -0,0 +1,36 @@
+package eventprocessor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_sqsEnvelope(t *testing.T) {
+	signature := "c2lnbmF0dXJl"
+	empty := ""
+	message := events.SQSMessage{
+		MessageId:   "msg-1",
+		Body:        notificationBody("1", "client-1"),
+		EventSource: "aws:sqs",
+		Attributes:  map[string]string{"ApproximateReceiveCount": "3", "SentTimestamp": "1767225600000"},
+		MessageAttributes: map[string]events.SQSMessageAttribute{
+			"signature": {DataType: "String", StringValue: &signature},
+			"empty":     {DataType: "String", StringValue: &empty},
+			"binary":    {DataType: "Binary", BinaryValue: []byte{1}},
+		},
+	}
+
+	t.Run("should carry the message's body, string attributes and metadata", func(t *testing.T) {
+		assert.Equal(t, Envelope{
+			MessageID:    "msg-1",
+			Body:         message.Body,
+			Attributes:   map[string]string{"signature": signature},
+			ReceiveCount: 3,
+			Source:       "aws:sqs",
+			SentAt:       time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		}, sqsEnvelope(message))
+	})
+}
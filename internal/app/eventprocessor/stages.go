@@ -0,0 +1,67 @@
+package eventprocessor
+
+import (
+	"context"
+	"errors"
+
+	"github.com/nivedita-verma/event-processor/internal/pkg/clientregistry"
+	"github.com/nivedita-verma/event-processor/internal/pkg/eventstore"
+	"go.uber.org/zap"
+)
+
+// DropPersisted drops events already in the store, so a redelivered event does
+// not run through its route again. Only the given store is checked, not the
+// destinations of other routes.
+func DropPersisted(reader eventstore.Reader) Stage {
+	return func(ctx context.Context, record *eventstore.Record) (Decision, error) {
+		_, err := reader.Get(ctx, record.ClientID, record.EventID)
+		if errors.Is(err, eventstore.ErrEventNotFound) {
+			return Continue, nil
+		}
+		if err != nil {
+			return Continue, err
+		}
+		return Drop, nil
+	}
+}
+
+// Enrich attaches the name, tier and delivery endpoints of the event's client
+// from the registry, so the Sender does not have to look them up. Events of
+// clients not in the registry continue without them.
+func Enrich(registry clientregistry.Api) Stage {
+	return func(ctx context.Context, record *eventstore.Record) (Decision, error) {
+		client, err := registry.Get(ctx, record.ClientID)
+		if errors.Is(err, clientregistry.ErrClientNotFound) {
+			return Continue, nil
+		}
+		if err != nil {
+			return Continue, err
+		}
+		record.Client = &eventstore.ClientMetadata{
+			Name:      client.Name,
+			Tier:      client.Tier,
+			Endpoints: client.Endpoints,
+		}
+		return Continue, nil
+	}
+}
+
+// RecordMetrics logs one structured "Event metrics" entry per event with its
+// type, delivery count and, when known, the time it spent queued, so they can
+// be aggregated with CloudWatch Logs Insights.
+func RecordMetrics(logger *zap.SugaredLogger) Stage {
+	return func(ctx context.Context, record *eventstore.Record) (Decision, error) {
+		metadata := record.Metadata
+		fields := []interface{}{
+			"eventId", record.EventID,
+			"clientId", record.ClientID,
+			"type", record.Type,
+			"receiveCount", metadata.ReceiveCount,
+		}
+		if !metadata.SentAt.IsZero() && !metadata.ReceivedAt.IsZero() {
+			fields = append(fields, "queuedMs", metadata.ReceivedAt.Sub(metadata.SentAt).Milliseconds())
+		}
+		logger.Infow("Event metrics", fields...)
+		return Continue, nil
+	}
+}
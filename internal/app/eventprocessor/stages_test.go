@@ -0,0 +1,141 @@
+package eventprocessor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nivedita-verma/event-processor/internal/pkg/clientregistry"
+	"github.com/nivedita-verma/event-processor/internal/pkg/eventstore"
+	"github.com/nivedita-verma/event-processor/pkg/eventspec"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type mockReader struct {
+	err error
+}
+
+func (m *mockReader) Get(ctx context.Context, clientID, eventID string) (eventstore.Record, error) {
+	return eventstore.Record{}, m.err
+}
+
+func (m *mockReader) ListByClient(ctx context.Context, clientID string, opts eventstore.ListOptions) (eventstore.Page, error) {
+	return eventstore.Page{}, m.err
+}
+
+func Test_DropPersisted(t *testing.T) {
+	store := eventstore.NewMemoryStore()
+	stored := eventstore.Record{Event: eventspec.Event{EventID: "1", ClientID: "client-1"}}
+	assert.NoError(t, store.Persist(context.Background(), stored))
+
+	t.Run("when the event is already stored", func(t *testing.T) {
+		decision, err := DropPersisted(store)(context.Background(), &stored)
+
+		t.Run("should drop it", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Equal(t, Drop, decision)
+		})
+	})
+
+	t.Run("when the event is not stored", func(t *testing.T) {
+		decision, err := DropPersisted(store)(context.Background(), &eventstore.Record{Event: eventspec.Event{EventID: "2", ClientID: "client-1"}})
+
+		t.Run("should continue", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Equal(t, Continue, decision)
+		})
+	})
+
+	t.Run("when the store cannot be read", func(t *testing.T) {
+		_, err := DropPersisted(&mockReader{err: assert.AnError})(context.Background(), &stored)
+
+		t.Run("should return the error", func(t *testing.T) {
+			assert.ErrorIs(t, err, assert.AnError)
+		})
+	})
+}
+
+type mockRegistry struct {
+	err error
+}
+
+func (m *mockRegistry) Get(ctx context.Context, clientID string) (clientregistry.Client, error) {
+	return clientregistry.Client{}, m.err
+}
+
+func Test_Enrich(t *testing.T) {
+	registry := clientregistry.NewMemoryRegistry(clientregistry.Client{
+		ClientID:  "client-1",
+		Name:      "Client One",
+		Tier:      "gold",
+		Endpoints: []string{"https://example.com/events"},
+	})
+
+	t.Run("when the client is in the registry", func(t *testing.T) {
+		record := &eventstore.Record{Event: eventspec.Event{EventID: "1", ClientID: "client-1"}}
+
+		decision, err := Enrich(registry)(context.Background(), record)
+
+		t.Run("should attach the client and continue", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Equal(t, Continue, decision)
+			assert.Equal(t, &eventstore.ClientMetadata{Name: "Client One", Tier: "gold", Endpoints: []string{"https://example.com/events"}}, record.Client)
+		})
+	})
+
+	t.Run("when the client is not in the registry", func(t *testing.T) {
+		record := &eventstore.Record{Event: eventspec.Event{EventID: "1", ClientID: "client-2"}}
+
+		decision, err := Enrich(registry)(context.Background(), record)
+
+		t.Run("should continue without a client", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Equal(t, Continue, decision)
+			assert.Nil(t, record.Client)
+		})
+	})
+
+	t.Run("when the registry cannot be read", func(t *testing.T) {
+		_, err := Enrich(&mockRegistry{err: assert.AnError})(context.Background(), &eventstore.Record{})
+
+		t.Run("should return the error", func(t *testing.T) {
+			assert.ErrorIs(t, err, assert.AnError)
+		})
+	})
+}
+
+func Test_RecordMetrics(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	receivedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	record := eventstore.Record{
+		Event: eventspec.Event{EventID: "1", ClientID: "client-1", Type: "notification"},
+		Metadata: eventstore.Metadata{
+			ReceivedAt:   receivedAt,
+			SentAt:       receivedAt.Add(-1500 * time.Millisecond),
+			ReceiveCount: 2,
+		},
+	}
+
+	decision, err := RecordMetrics(zap.New(core).Sugar())(context.Background(), &record)
+
+	t.Run("should continue", func(t *testing.T) {
+		assert.NoError(t, err)
+		assert.Equal(t, Continue, decision)
+	})
+
+	t.Run("should log the event's metrics", func(t *testing.T) {
+		entries := logs.FilterMessage("Event metrics").All()
+		if assert.Len(t, entries, 1) {
+			assert.Equal(t, map[string]interface{}{
+				"eventId":      "1",
+				"clientId":     "client-1",
+				"type":         "notification",
+				"receiveCount": int64(2),
+				"queuedMs":     int64(1500),
+			}, entries[0].ContextMap())
+		}
+	})
+}
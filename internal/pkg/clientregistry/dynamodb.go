@@ -0,0 +1,53 @@
+package clientregistry
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"go.uber.org/zap"
+)
+
+type dynamoDBAPI interface {
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+}
+
+// DynamoDBRegistry reads clients from a DynamoDB table keyed by ClientID.
+type DynamoDBRegistry struct {
+	client    dynamoDBAPI
+	tableName string
+	logger    *zap.SugaredLogger
+}
+
+func NewDynamoDBRegistry(api dynamoDBAPI, tableName string, logger *zap.SugaredLogger) *DynamoDBRegistry {
+	return &DynamoDBRegistry{
+		client:    api,
+		tableName: tableName,
+		logger:    logger,
+	}
+}
+
+func (r *DynamoDBRegistry) Get(ctx context.Context, clientID string) (Client, error) {
+	r.logger.Debugf("Reading client %s from the registry", clientID)
+
+	output, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.tableName),
+		Key: map[string]types.AttributeValue{
+			"ClientID": &types.AttributeValueMemberS{Value: clientID},
+		},
+	})
+	if err != nil {
+		return Client{}, err
+	}
+	if output.Item == nil {
+		return Client{}, fmt.Errorf("client %s: %w", clientID, ErrClientNotFound)
+	}
+	var client Client
+	if err := attributevalue.UnmarshalMap(output.Item, &client); err != nil {
+		return Client{}, fmt.Errorf("failed to unmarshal client %s: %w", clientID, err)
+	}
+	return client, nil
+}
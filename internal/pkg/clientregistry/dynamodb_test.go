@@ -0,0 +1,94 @@
+package clientregistry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/nivedita-verma/event-processor/pkg/eventspec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+type mockDynamoDBClient struct {
+	mock.Mock
+}
+
+func (m *mockDynamoDBClient) GetItem(ctx context.Context, input *dynamodb.GetItemInput, opts ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	args := m.Called(ctx, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dynamodb.GetItemOutput), nil
+}
+
+func Test_DynamoDBRegistry_Get(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	withKey := mock.MatchedBy(func(input *dynamodb.GetItemInput) bool {
+		key, ok := input.Key["ClientID"].(*types.AttributeValueMemberS)
+		return *input.TableName == "clientTable" && ok && key.Value == "client-1"
+	})
+
+	t.Run("when the client exists", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		client.On("GetItem", mock.Anything, withKey).Return(&dynamodb.GetItemOutput{
+			Item: map[string]types.AttributeValue{
+				"ClientID":  &types.AttributeValueMemberS{Value: "client-1"},
+				"Name":      &types.AttributeValueMemberS{Value: "Client One"},
+				"Tier":      &types.AttributeValueMemberS{Value: "gold"},
+				"Endpoints": &types.AttributeValueMemberL{Value: []types.AttributeValue{&types.AttributeValueMemberS{Value: "https://example.com/events"}}},
+			},
+		}, nil)
+
+		result, err := NewDynamoDBRegistry(client, "clientTable", logger).Get(context.Background(), "client-1")
+
+		t.Run("should return the client", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Equal(t, Client{ClientID: "client-1", Name: "Client One", Tier: "gold", Endpoints: []string{"https://example.com/events"}}, result)
+		})
+	})
+
+	t.Run("when the client has a signing key", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		client.On("GetItem", mock.Anything, withKey).Return(&dynamodb.GetItemOutput{
+			Item: map[string]types.AttributeValue{
+				"ClientID": &types.AttributeValueMemberS{Value: "client-1"},
+				"SigningKey": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+					"Algorithm": &types.AttributeValueMemberS{Value: "HMAC-SHA256"},
+					"Key":       &types.AttributeValueMemberB{Value: []byte("secret")},
+				}},
+			},
+		}, nil)
+
+		result, err := NewDynamoDBRegistry(client, "clientTable", logger).Get(context.Background(), "client-1")
+
+		t.Run("should return the key", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Equal(t, &eventspec.VerificationKey{Algorithm: eventspec.SignatureHMACSHA256, Key: []byte("secret")}, result.SigningKey)
+		})
+	})
+
+	t.Run("when the client does not exist", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		client.On("GetItem", mock.Anything, withKey).Return(&dynamodb.GetItemOutput{}, nil)
+
+		_, err := NewDynamoDBRegistry(client, "clientTable", logger).Get(context.Background(), "client-1")
+
+		t.Run("should return ErrClientNotFound", func(t *testing.T) {
+			assert.ErrorIs(t, err, ErrClientNotFound)
+		})
+	})
+
+	t.Run("when GetItem returns an error", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		client.On("GetItem", mock.Anything, withKey).Return(nil, assert.AnError)
+
+		_, err := NewDynamoDBRegistry(client, "clientTable", logger).Get(context.Background(), "client-1")
+
+		t.Run("should return the error", func(t *testing.T) {
+			assert.ErrorIs(t, err, assert.AnError)
+		})
+	})
+}
@@ -0,0 +1,39 @@
+package clientregistry
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// MemoryRegistry keeps clients in memory. It is intended for tests and local
+// runs.
+type MemoryRegistry struct {
+	mu      sync.RWMutex
+	clients map[string]Client
+}
+
+func NewMemoryRegistry(clients ...Client) *MemoryRegistry {
+	r := &MemoryRegistry{clients: make(map[string]Client)}
+	for _, client := range clients {
+		r.Put(client)
+	}
+	return r
+}
+
+// Put adds a client, replacing any client with the same ID.
+func (r *MemoryRegistry) Put(client Client) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.clients[client.ClientID] = client
+}
+
+func (r *MemoryRegistry) Get(_ context.Context, clientID string) (Client, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	client, ok := r.clients[clientID]
+	if !ok {
+		return Client{}, fmt.Errorf("client %s: %w", clientID, ErrClientNotFound)
+	}
+	return client, nil
+}
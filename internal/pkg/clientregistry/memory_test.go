@@ -0,0 +1,38 @@
+package clientregistry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MemoryRegistry(t *testing.T) {
+	registry := NewMemoryRegistry(Client{ClientID: "client-1", Name: "Client One"})
+
+	t.Run("when the client exists", func(t *testing.T) {
+		client, err := registry.Get(context.Background(), "client-1")
+
+		t.Run("should return it", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Equal(t, "Client One", client.Name)
+		})
+	})
+
+	t.Run("when the client is replaced", func(t *testing.T) {
+		registry.Put(Client{ClientID: "client-1", Name: "Renamed"})
+		client, _ := registry.Get(context.Background(), "client-1")
+
+		t.Run("should return the new client", func(t *testing.T) {
+			assert.Equal(t, "Renamed", client.Name)
+		})
+	})
+
+	t.Run("when the client does not exist", func(t *testing.T) {
+		_, err := registry.Get(context.Background(), "unknown")
+
+		t.Run("should return ErrClientNotFound", func(t *testing.T) {
+			assert.ErrorIs(t, err, ErrClientNotFound)
+		})
+	})
+}
@@ -0,0 +1,85 @@
+package clientregistry
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/nivedita-verma/event-processor/pkg/eventspec"
+)
+
+// ErrClientNotFound is returned when a client is not in the registry.
+var ErrClientNotFound = errors.New("client not found")
+
+// Client describes an onboarded client.
+type Client struct {
+	ClientID string
+	Name     string
+	Tier     string
+	// Endpoints are where the Sender delivers the client's events.
+	Endpoints []string `dynamodbav:",omitempty"`
+	// Active is false for suspended clients.
+	Active bool
+	// AllowedEventTypes are the event types the client may send. A client
+	// with none may not send any.
+	AllowedEventTypes []string `dynamodbav:",omitempty"`
+	// SigningKey verifies the signatures of the client's events, and is nil
+	// for clients that do not sign them.
+	SigningKey *eventspec.VerificationKey `dynamodbav:",omitempty"`
+}
+
+// Allows reports whether the client may send events of the given type.
+func (c Client) Allows(eventType string) bool {
+	return slices.Contains(c.AllowedEventTypes, eventType)
+}
+
+// Api looks up clients by ID, returning ErrClientNotFound for unknown clients.
+type Api interface {
+	Get(ctx context.Context, clientID string) (Client, error)
+}
+
+type cacheEntry struct {
+	client    Client
+	err       error
+	expiresAt time.Time
+}
+
+// CachedRegistry keeps the clients looked up in another registry for a TTL, so
+// a Lambda container reads each client once per TTL rather than once per
+// event. Unknown clients are cached too; other errors are not.
+type CachedRegistry struct {
+	registry Api
+	ttl      time.Duration
+	now      func() time.Time
+	mu       sync.Mutex
+	entries  map[string]cacheEntry
+}
+
+func NewCachedRegistry(registry Api, ttl time.Duration) *CachedRegistry {
+	return &CachedRegistry{
+		registry: registry,
+		ttl:      ttl,
+		now:      time.Now,
+		entries:  make(map[string]cacheEntry),
+	}
+}
+
+func (r *CachedRegistry) Get(ctx context.Context, clientID string) (Client, error) {
+	r.mu.Lock()
+	entry, ok := r.entries[clientID]
+	r.mu.Unlock()
+	if ok && r.now().Before(entry.expiresAt) {
+		return entry.client, entry.err
+	}
+
+	client, err := r.registry.Get(ctx, clientID)
+	if err != nil && !errors.Is(err, ErrClientNotFound) {
+		return Client{}, err
+	}
+	r.mu.Lock()
+	r.entries[clientID] = cacheEntry{client: client, err: err, expiresAt: r.now().Add(r.ttl)}
+	r.mu.Unlock()
+	return client, err
+}
@@ -0,0 +1,99 @@
+package clientregistry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockRegistry struct {
+	mock.Mock
+}
+
+func (m *mockRegistry) Get(ctx context.Context, clientID string) (Client, error) {
+	args := m.Called(ctx, clientID)
+	return args.Get(0).(Client), args.Error(1)
+}
+
+func Test_Client_Allows(t *testing.T) {
+	client := Client{ClientID: "client-1", AllowedEventTypes: []string{"notification"}}
+
+	t.Run("should allow the listed types only", func(t *testing.T) {
+		assert.True(t, client.Allows("notification"))
+		assert.False(t, client.Allows("transaction"))
+		assert.False(t, Client{}.Allows("notification"))
+	})
+}
+
+func Test_CachedRegistry_Get(t *testing.T) {
+	client := Client{ClientID: "client-1", Name: "Client One", Tier: "gold"}
+	start := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("when the client is read again within the TTL", func(t *testing.T) {
+		registry := &mockRegistry{}
+		registry.On("Get", mock.Anything, "client-1").Return(client, nil).Once()
+		cached := NewCachedRegistry(registry, time.Minute)
+		cached.now = func() time.Time { return start }
+
+		first, err1 := cached.Get(context.Background(), "client-1")
+		cached.now = func() time.Time { return start.Add(59 * time.Second) }
+		second, err2 := cached.Get(context.Background(), "client-1")
+
+		t.Run("should read the registry once", func(t *testing.T) {
+			assert.NoError(t, err1)
+			assert.NoError(t, err2)
+			assert.Equal(t, client, first)
+			assert.Equal(t, client, second)
+			registry.AssertNumberOfCalls(t, "Get", 1)
+		})
+	})
+
+	t.Run("when the TTL has passed", func(t *testing.T) {
+		registry := &mockRegistry{}
+		registry.On("Get", mock.Anything, "client-1").Return(client, nil)
+		cached := NewCachedRegistry(registry, time.Minute)
+		cached.now = func() time.Time { return start }
+
+		cached.Get(context.Background(), "client-1")
+		cached.now = func() time.Time { return start.Add(time.Minute) }
+		cached.Get(context.Background(), "client-1")
+
+		t.Run("should read the registry again", func(t *testing.T) {
+			registry.AssertNumberOfCalls(t, "Get", 2)
+		})
+	})
+
+	t.Run("when the client is not found", func(t *testing.T) {
+		registry := &mockRegistry{}
+		registry.On("Get", mock.Anything, "unknown").Return(Client{}, ErrClientNotFound).Once()
+		cached := NewCachedRegistry(registry, time.Minute)
+
+		_, err1 := cached.Get(context.Background(), "unknown")
+		_, err2 := cached.Get(context.Background(), "unknown")
+
+		t.Run("should cache the miss", func(t *testing.T) {
+			assert.ErrorIs(t, err1, ErrClientNotFound)
+			assert.ErrorIs(t, err2, ErrClientNotFound)
+			registry.AssertNumberOfCalls(t, "Get", 1)
+		})
+	})
+
+	t.Run("when the registry returns an error", func(t *testing.T) {
+		registry := &mockRegistry{}
+		registry.On("Get", mock.Anything, "client-1").Return(Client{}, assert.AnError).Once()
+		registry.On("Get", mock.Anything, "client-1").Return(client, nil).Once()
+		cached := NewCachedRegistry(registry, time.Minute)
+
+		_, err := cached.Get(context.Background(), "client-1")
+		retried, retryErr := cached.Get(context.Background(), "client-1")
+
+		t.Run("should return the error without caching it", func(t *testing.T) {
+			assert.ErrorIs(t, err, assert.AnError)
+			assert.NoError(t, retryErr)
+			assert.Equal(t, client, retried)
+		})
+	})
+}
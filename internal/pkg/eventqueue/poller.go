@@ -0,0 +1,185 @@
+package eventqueue
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"go.uber.org/zap"
+)
+
+// BatchHandler handles a batch of SQS messages as a Lambda SQS event source
+// would deliver them, reporting the messages to redeliver.
+type BatchHandler func(context.Context, events.SQSEvent) (events.SQSEventResponse, error)
+
+type sqsConsumerAPI interface {
+	ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error)
+	DeleteMessageBatch(ctx context.Context, params *sqs.DeleteMessageBatchInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageBatchOutput, error)
+	ChangeMessageVisibilityBatch(ctx context.Context, params *sqs.ChangeMessageVisibilityBatchInput, optFns ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityBatchOutput, error)
+}
+
+// Poller long-polls an SQS queue and hands each batch received to a handler,
+// standing in for the Lambda SQS event source: succeeded messages are deleted
+// and failed messages are made visible again after the retry delay.
+type Poller struct {
+	client       sqsConsumerAPI
+	queueURL     string
+	handler      BatchHandler
+	logger       *zap.SugaredLogger
+	batchSize    int32
+	waitTime     time.Duration
+	retryDelay   time.Duration
+	errorBackoff time.Duration
+}
+
+type PollerOption func(*Poller)
+
+// WithBatchSize sets the most messages received at once, from 1 to 10.
+// Defaults to 10.
+func WithBatchSize(size int) PollerOption {
+	return func(p *Poller) {
+		p.batchSize = int32(min(max(size, 1), 10))
+	}
+}
+
+// WithRetryDelay sets how long a failed message stays invisible before it is
+// redelivered. Defaults to 30 seconds.
+func WithRetryDelay(delay time.Duration) PollerOption {
+	return func(p *Poller) {
+		p.retryDelay = delay
+	}
+}
+
+func NewPoller(api sqsConsumerAPI, queueURL string, handler BatchHandler, logger *zap.SugaredLogger, opts ...PollerOption) *Poller {
+	poller := &Poller{
+		client:       api,
+		queueURL:     queueURL,
+		handler:      handler,
+		logger:       logger,
+		batchSize:    10,
+		waitTime:     20 * time.Second,
+		retryDelay:   30 * time.Second,
+		errorBackoff: 5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(poller)
+	}
+	return poller
+}
+
+// Run polls until the context is cancelled. A batch being handled when the
+// context is cancelled is finished, and its messages deleted or released,
+// before Run returns.
+func (p *Poller) Run(ctx context.Context) {
+	p.logger.Infof("Polling queue %s", p.queueURL)
+	for ctx.Err() == nil {
+		output, err := p.client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:                    aws.String(p.queueURL),
+			MaxNumberOfMessages:         p.batchSize,
+			WaitTimeSeconds:             int32(p.waitTime.Seconds()),
+			MessageSystemAttributeNames: []types.MessageSystemAttributeName{types.MessageSystemAttributeNameAll},
+			MessageAttributeNames:       []string{"All"},
+		})
+		if ctx.Err() != nil {
+			break
+		}
+		if err != nil {
+			p.logger.Errorf("failed to receive messages from %s: %v", p.queueURL, err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(p.errorBackoff):
+			}
+			continue
+		}
+		if len(output.Messages) > 0 {
+			p.handleBatch(context.WithoutCancel(ctx), output.Messages)
+		}
+	}
+	p.logger.Infof("Stopped polling queue %s", p.queueURL)
+}
+
+// handleBatch hands the messages to the handler, then deletes those that
+// succeeded and releases those that failed. A handler error fails them all.
+func (p *Poller) handleBatch(ctx context.Context, messages []types.Message) {
+	sqsEvent := events.SQSEvent{Records: make([]events.SQSMessage, len(messages))}
+	for i, message := range messages {
+		sqsEvent.Records[i] = toSQSMessage(message)
+	}
+
+	failed := make(map[string]bool)
+	response, err := p.handler(ctx, sqsEvent)
+	if err != nil {
+		p.logger.Errorf("failed to handle batch of %d messages: %v", len(messages), err)
+		for _, message := range sqsEvent.Records {
+			failed[message.MessageId] = true
+		}
+	}
+	for _, itemFailure := range response.BatchItemFailures {
+		failed[itemFailure.ItemIdentifier] = true
+	}
+
+	var deletes []types.DeleteMessageBatchRequestEntry
+	var releases []types.ChangeMessageVisibilityBatchRequestEntry
+	for i, message := range messages {
+		// Entry IDs only need to be unique within the request
+		id := aws.String(strconv.Itoa(i))
+		if failed[aws.ToString(message.MessageId)] {
+			releases = append(releases, types.ChangeMessageVisibilityBatchRequestEntry{Id: id, ReceiptHandle: message.ReceiptHandle, VisibilityTimeout: int32(p.retryDelay.Seconds())})
+		} else {
+			deletes = append(deletes, types.DeleteMessageBatchRequestEntry{Id: id, ReceiptHandle: message.ReceiptHandle})
+		}
+	}
+
+	if len(deletes) > 0 {
+		output, err := p.client.DeleteMessageBatch(ctx, &sqs.DeleteMessageBatchInput{QueueUrl: aws.String(p.queueURL), Entries: deletes})
+		if err != nil {
+			p.logger.Errorf("failed to delete %d messages, they will be redelivered: %v", len(deletes), err)
+		} else {
+			p.logFailedEntries("delete", output.Failed)
+		}
+	}
+	if len(releases) > 0 {
+		output, err := p.client.ChangeMessageVisibilityBatch(ctx, &sqs.ChangeMessageVisibilityBatchInput{QueueUrl: aws.String(p.queueURL), Entries: releases})
+		if err != nil {
+			p.logger.Errorf("failed to release %d messages, they will be redelivered after the visibility timeout: %v", len(releases), err)
+		} else {
+			p.logFailedEntries("release", output.Failed)
+		}
+	}
+}
+
+func (p *Poller) logFailedEntries(action string, entries []types.BatchResultErrorEntry) {
+	for _, entry := range entries {
+		p.logger.Errorf("failed to %s message entry %s: %s", action, aws.ToString(entry.Id), aws.ToString(entry.Message))
+	}
+}
+
+// toSQSMessage converts a received message to the form Lambda delivers it in.
+func toSQSMessage(message types.Message) events.SQSMessage {
+	sqsMessage := events.SQSMessage{
+		MessageId:              aws.ToString(message.MessageId),
+		ReceiptHandle:          aws.ToString(message.ReceiptHandle),
+		Body:                   aws.ToString(message.Body),
+		Md5OfBody:              aws.ToString(message.MD5OfBody),
+		Md5OfMessageAttributes: aws.ToString(message.MD5OfMessageAttributes),
+		Attributes:             message.Attributes,
+		EventSource:            "aws:sqs",
+	}
+	if len(message.MessageAttributes) > 0 {
+		sqsMessage.MessageAttributes = make(map[string]events.SQSMessageAttribute, len(message.MessageAttributes))
+		for name, value := range message.MessageAttributes {
+			sqsMessage.MessageAttributes[name] = events.SQSMessageAttribute{
+				DataType:         aws.ToString(value.DataType),
+				StringValue:      value.StringValue,
+				BinaryValue:      value.BinaryValue,
+				StringListValues: value.StringListValues,
+				BinaryListValues: value.BinaryListValues,
+			}
+		}
+	}
+	return sqsMessage
+}
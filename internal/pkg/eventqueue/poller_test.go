@@ -0,0 +1,149 @@
+package eventqueue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+type mockSQSConsumer struct {
+	mock.Mock
+}
+
+func (m *mockSQSConsumer) ReceiveMessage(ctx context.Context, input *sqs.ReceiveMessageInput, opts ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+	args := m.Called(ctx, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sqs.ReceiveMessageOutput), nil
+}
+
+func (m *mockSQSConsumer) DeleteMessageBatch(ctx context.Context, input *sqs.DeleteMessageBatchInput, opts ...func(*sqs.Options)) (*sqs.DeleteMessageBatchOutput, error) {
+	args := m.Called(ctx, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sqs.DeleteMessageBatchOutput), nil
+}
+
+func (m *mockSQSConsumer) ChangeMessageVisibilityBatch(ctx context.Context, input *sqs.ChangeMessageVisibilityBatchInput, opts ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityBatchOutput, error) {
+	args := m.Called(ctx, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sqs.ChangeMessageVisibilityBatchOutput), nil
+}
+
+func newReceivedMessage(id string) types.Message {
+	return types.Message{
+		MessageId:     aws.String(id),
+		ReceiptHandle: aws.String("receipt-" + id),
+		Body:          aws.String(`{"eventId":"` + id + `"}`),
+		Attributes:    map[string]string{"ApproximateReceiveCount": "1"},
+		MessageAttributes: map[string]types.MessageAttributeValue{
+			"signature": {DataType: aws.String("String"), StringValue: aws.String("c2ln")},
+		},
+	}
+}
+
+func Test_Poller_Run(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+
+	t.Run("when a batch is received", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		client := &mockSQSConsumer{}
+		client.On("ReceiveMessage", mock.Anything, mock.MatchedBy(func(input *sqs.ReceiveMessageInput) bool {
+			return *input.QueueUrl == "queue-url" && input.MaxNumberOfMessages == 2 && input.WaitTimeSeconds == 20
+		})).Return(&sqs.ReceiveMessageOutput{Messages: []types.Message{newReceivedMessage("msg-1"), newReceivedMessage("msg-2")}}, nil).Once()
+		client.On("DeleteMessageBatch", mock.Anything, &sqs.DeleteMessageBatchInput{
+			QueueUrl: aws.String("queue-url"),
+			Entries:  []types.DeleteMessageBatchRequestEntry{{Id: aws.String("0"), ReceiptHandle: aws.String("receipt-msg-1")}},
+		}).Return(&sqs.DeleteMessageBatchOutput{}, nil)
+		client.On("ChangeMessageVisibilityBatch", mock.Anything, &sqs.ChangeMessageVisibilityBatchInput{
+			QueueUrl: aws.String("queue-url"),
+			Entries:  []types.ChangeMessageVisibilityBatchRequestEntry{{Id: aws.String("1"), ReceiptHandle: aws.String("receipt-msg-2"), VisibilityTimeout: 60}},
+		}).Return(&sqs.ChangeMessageVisibilityBatchOutput{}, nil)
+
+		var received events.SQSEvent
+		handler := func(ctx context.Context, sqsEvent events.SQSEvent) (events.SQSEventResponse, error) {
+			received = sqsEvent
+			// Shutting down mid-batch must not stop the batch from completing
+			cancel()
+			return events.SQSEventResponse{BatchItemFailures: []events.SQSBatchItemFailure{{ItemIdentifier: "msg-2"}}}, nil
+		}
+
+		NewPoller(client, "queue-url", handler, logger, WithBatchSize(2), WithRetryDelay(time.Minute)).Run(ctx)
+
+		t.Run("should hand the messages to the handler as Lambda would", func(t *testing.T) {
+			if assert.Len(t, received.Records, 2) {
+				message := received.Records[0]
+				assert.Equal(t, "msg-1", message.MessageId)
+				assert.Equal(t, "receipt-msg-1", message.ReceiptHandle)
+				assert.Equal(t, `{"eventId":"msg-1"}`, message.Body)
+				assert.Equal(t, "aws:sqs", message.EventSource)
+				assert.Equal(t, "1", message.Attributes["ApproximateReceiveCount"])
+				assert.Equal(t, "c2ln", *message.MessageAttributes["signature"].StringValue)
+			}
+		})
+
+		t.Run("should delete succeeded messages and release failed ones", func(t *testing.T) {
+			client.AssertExpectations(t)
+		})
+	})
+
+	t.Run("when the handler fails", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		client := &mockSQSConsumer{}
+		client.On("ReceiveMessage", mock.Anything, mock.Anything).Return(&sqs.ReceiveMessageOutput{Messages: []types.Message{newReceivedMessage("msg-1")}}, nil).Once()
+		client.On("ChangeMessageVisibilityBatch", mock.Anything, mock.MatchedBy(func(input *sqs.ChangeMessageVisibilityBatchInput) bool {
+			return len(input.Entries) == 1 && input.Entries[0].VisibilityTimeout == 30
+		})).Return(&sqs.ChangeMessageVisibilityBatchOutput{}, nil)
+
+		handler := func(context.Context, events.SQSEvent) (events.SQSEventResponse, error) {
+			cancel()
+			return events.SQSEventResponse{}, assert.AnError
+		}
+
+		NewPoller(client, "queue-url", handler, logger).Run(ctx)
+
+		t.Run("should release every message", func(t *testing.T) {
+			client.AssertExpectations(t)
+			client.AssertNotCalled(t, "DeleteMessageBatch", mock.Anything, mock.Anything)
+		})
+	})
+
+	t.Run("when receiving fails", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		client := &mockSQSConsumer{}
+		client.On("ReceiveMessage", mock.Anything, mock.Anything).Return(nil, assert.AnError).Once()
+		client.On("ReceiveMessage", mock.Anything, mock.Anything).Run(func(mock.Arguments) { cancel() }).Return(nil, context.Canceled).Once()
+
+		poller := NewPoller(client, "queue-url", nil, logger)
+		poller.errorBackoff = time.Millisecond
+		poller.Run(ctx)
+
+		t.Run("should keep polling until cancelled", func(t *testing.T) {
+			client.AssertNumberOfCalls(t, "ReceiveMessage", 2)
+		})
+	})
+
+	t.Run("when the context is already cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		client := &mockSQSConsumer{}
+
+		NewPoller(client, "queue-url", nil, logger).Run(ctx)
+
+		t.Run("should not poll", func(t *testing.T) {
+			client.AssertNotCalled(t, "ReceiveMessage", mock.Anything, mock.Anything)
+		})
+	})
+}
@@ -0,0 +1,51 @@
+package eventqueue
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"go.uber.org/zap"
+)
+
+// Sender sends event messages as produced, for ingresses that forward events
+// to the event queue rather than processing them.
+type Sender interface {
+	Send(ctx context.Context, body string, attributes map[string]string) error
+}
+
+// SQSSender sends messages to an SQS queue, with the given attributes as
+// string message attributes.
+type SQSSender struct {
+	client   sqsAPI
+	queueURL string
+	logger   *zap.SugaredLogger
+}
+
+func NewSQSSender(api sqsAPI, queueURL string, logger *zap.SugaredLogger) *SQSSender {
+	return &SQSSender{
+		client:   api,
+		queueURL: queueURL,
+		logger:   logger,
+	}
+}
+
+func (s *SQSSender) Send(ctx context.Context, body string, attributes map[string]string) error {
+	input := &sqs.SendMessageInput{
+		QueueUrl:    aws.String(s.queueURL),
+		MessageBody: aws.String(body),
+	}
+	for name, value := range attributes {
+		if input.MessageAttributes == nil {
+			input.MessageAttributes = make(map[string]types.MessageAttributeValue, len(attributes))
+		}
+		input.MessageAttributes[name] = types.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(value)}
+	}
+	output, err := s.client.SendMessage(ctx, input)
+	if err != nil {
+		return err
+	}
+	s.logger.Infof("Sent message ID %s to %s", aws.ToString(output.MessageId), s.queueURL)
+	return nil
+}
@@ -0,0 +1,62 @@
+package eventqueue
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func Test_SQSSender_Send(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	body := `{"eventId":"1","clientId":"client-1","type":"notification","data":{}}`
+
+	t.Run("when the message has attributes", func(t *testing.T) {
+		client := &mockSQSClient{}
+		client.On("SendMessage", mock.Anything, &sqs.SendMessageInput{
+			QueueUrl:    aws.String("queue-url"),
+			MessageBody: aws.String(body),
+			MessageAttributes: map[string]types.MessageAttributeValue{
+				"signature": {DataType: aws.String("String"), StringValue: aws.String("c2ln")},
+			},
+		}).Return(&sqs.SendMessageOutput{MessageId: aws.String("msg-1")}, nil)
+
+		err := NewSQSSender(client, "queue-url", logger).Send(context.Background(), body, map[string]string{"signature": "c2ln"})
+
+		t.Run("should send the body as is with the attributes", func(t *testing.T) {
+			assert.NoError(t, err)
+			client.AssertExpectations(t)
+		})
+	})
+
+	t.Run("when the message has no attributes", func(t *testing.T) {
+		client := &mockSQSClient{}
+		client.On("SendMessage", mock.Anything, &sqs.SendMessageInput{
+			QueueUrl:    aws.String("queue-url"),
+			MessageBody: aws.String(body),
+		}).Return(&sqs.SendMessageOutput{MessageId: aws.String("msg-1")}, nil)
+
+		err := NewSQSSender(client, "queue-url", logger).Send(context.Background(), body, nil)
+
+		t.Run("should send the body without attributes", func(t *testing.T) {
+			assert.NoError(t, err)
+			client.AssertExpectations(t)
+		})
+	})
+
+	t.Run("when SendMessage returns an error", func(t *testing.T) {
+		client := &mockSQSClient{}
+		client.On("SendMessage", mock.Anything, mock.Anything).Return(nil, assert.AnError)
+
+		err := NewSQSSender(client, "queue-url", logger).Send(context.Background(), body, nil)
+
+		t.Run("should return the error", func(t *testing.T) {
+			assert.ErrorIs(t, err, assert.AnError)
+		})
+	})
+}
@@ -0,0 +1,69 @@
+package eventqueue
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/nivedita-verma/event-processor/internal/pkg/eventstore"
+	"github.com/nivedita-verma/event-processor/internal/pkg/failure"
+	"go.uber.org/zap"
+)
+
+// Api publishes records to a queue. Errors that will not succeed on retry are
+// marked with failure.Permanent; all other errors are retryable.
+type Api interface {
+	Publish(context.Context, eventstore.Record) error
+}
+
+type sqsAPI interface {
+	SendMessage(ctx context.Context, params *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error)
+}
+
+// SQSPublisher sends records to an SQS queue as JSON, with the client, type and
+// priority as message attributes so consumers can filter without decoding.
+type SQSPublisher struct {
+	client   sqsAPI
+	queueURL string
+	marshal  func(interface{}) ([]byte, error)
+	logger   *zap.SugaredLogger
+}
+
+func NewSQSPublisher(api sqsAPI, queueURL string, logger *zap.SugaredLogger) *SQSPublisher {
+	return &SQSPublisher{
+		client:   api,
+		queueURL: queueURL,
+		marshal:  json.Marshal,
+		logger:   logger,
+	}
+}
+
+func (p *SQSPublisher) Publish(ctx context.Context, record eventstore.Record) error {
+	body, err := p.marshal(record)
+	if err != nil {
+		return failure.Permanent(err)
+	}
+
+	p.logger.Infof("Publishing event ID %s with priority %s", record.EventID, record.Priority)
+
+	attributes := make(map[string]types.MessageAttributeValue)
+	for name, value := range map[string]string{
+		"clientId": record.ClientID,
+		"type":     record.Type,
+		"priority": string(record.Priority),
+	} {
+		// SQS rejects empty attribute values
+		if value != "" {
+			attributes[name] = types.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(value)}
+		}
+	}
+
+	_, err = p.client.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:          aws.String(p.queueURL),
+		MessageBody:       aws.String(string(body)),
+		MessageAttributes: attributes,
+	})
+	return err
+}
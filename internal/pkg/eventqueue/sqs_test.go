@@ -0,0 +1,113 @@
+package eventqueue
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/nivedita-verma/event-processor/internal/pkg/eventstore"
+	"github.com/nivedita-verma/event-processor/internal/pkg/failure"
+	"github.com/nivedita-verma/event-processor/pkg/eventspec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+type mockSQSClient struct {
+	mock.Mock
+}
+
+func (m *mockSQSClient) SendMessage(ctx context.Context, input *sqs.SendMessageInput, opts ...func(*sqs.Options)) (*sqs.SendMessageOutput, error) {
+	args := m.Called(ctx, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sqs.SendMessageOutput), nil
+}
+
+func Test_NewSQSPublisher(t *testing.T) {
+	client := &mockSQSClient{}
+	publisher := NewSQSPublisher(client, "queue-url", zap.NewNop().Sugar())
+
+	assert.Equal(t, client, publisher.client)
+	assert.Equal(t, "queue-url", publisher.queueURL)
+}
+
+func Test_SQSPublisher_Publish(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	record := eventstore.Record{
+		Event:    eventspec.Event{EventID: "1", ClientID: "client-1", Type: "monitoringAlert", Data: map[string]interface{}{"severity": "critical"}},
+		Priority: eventspec.PriorityCritical,
+	}
+
+	t.Run("when SendMessage is successful", func(t *testing.T) {
+		client := &mockSQSClient{}
+		publisher := NewSQSPublisher(client, "queue-url", logger)
+		body, err := json.Marshal(record)
+		assert.NoError(t, err)
+
+		client.On("SendMessage", mock.Anything, &sqs.SendMessageInput{
+			QueueUrl:    aws.String("queue-url"),
+			MessageBody: aws.String(string(body)),
+			MessageAttributes: map[string]types.MessageAttributeValue{
+				"clientId": {DataType: aws.String("String"), StringValue: aws.String("client-1")},
+				"type":     {DataType: aws.String("String"), StringValue: aws.String("monitoringAlert")},
+				"priority": {DataType: aws.String("String"), StringValue: aws.String("critical")},
+			},
+		}).Return(&sqs.SendMessageOutput{}, nil)
+
+		err = publisher.Publish(context.Background(), record)
+
+		t.Run("should send the record with its attributes", func(t *testing.T) {
+			assert.NoError(t, err)
+			client.AssertExpectations(t)
+		})
+	})
+
+	t.Run("when the record has no priority", func(t *testing.T) {
+		client := &mockSQSClient{}
+		publisher := NewSQSPublisher(client, "queue-url", logger)
+		client.On("SendMessage", mock.Anything, mock.MatchedBy(func(input *sqs.SendMessageInput) bool {
+			_, ok := input.MessageAttributes["priority"]
+			return !ok
+		})).Return(&sqs.SendMessageOutput{}, nil)
+
+		err := publisher.Publish(context.Background(), eventstore.Record{Event: record.Event})
+
+		t.Run("should leave out the empty attribute", func(t *testing.T) {
+			assert.NoError(t, err)
+			client.AssertExpectations(t)
+		})
+	})
+
+	t.Run("when SendMessage returns an error", func(t *testing.T) {
+		client := &mockSQSClient{}
+		publisher := NewSQSPublisher(client, "queue-url", logger)
+		client.On("SendMessage", mock.Anything, mock.Anything).Return(nil, assert.AnError)
+
+		err := publisher.Publish(context.Background(), record)
+
+		t.Run("should return the error as retryable", func(t *testing.T) {
+			assert.ErrorIs(t, err, assert.AnError)
+			assert.True(t, failure.IsRetryable(err))
+		})
+	})
+
+	t.Run("when the record cannot be marshalled", func(t *testing.T) {
+		client := &mockSQSClient{}
+		publisher := NewSQSPublisher(client, "queue-url", logger)
+		publisher.marshal = func(interface{}) ([]byte, error) {
+			return nil, assert.AnError
+		}
+
+		err := publisher.Publish(context.Background(), record)
+
+		t.Run("should return a permanent error", func(t *testing.T) {
+			assert.ErrorIs(t, err, assert.AnError)
+			assert.True(t, failure.IsPermanent(err))
+		})
+	})
+}
@@ -2,10 +2,139 @@ package eventstore
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/nivedita-verma/event-processor/internal/pkg/fieldcrypt"
 	"github.com/nivedita-verma/event-processor/pkg/eventspec"
 )
 
+var (
+	// ErrDuplicateEvent is returned when an event with the same ClientID and
+	// EventID has already been persisted. Callers should treat it as success.
+	ErrDuplicateEvent = errors.New("event already persisted")
+	// ErrConflictingEvent is returned when an event with the same ClientID and
+	// EventID has already been persisted with a different type or data.
+	ErrConflictingEvent = errors.New("event already persisted with different content")
+	// ErrEventNotFound is returned by Get when no event with the given ClientID
+	// and EventID has been persisted.
+	ErrEventNotFound = errors.New("event not found")
+	// ErrInvalidPageToken is returned by ListByClient when the page token was not
+	// issued by a previous call.
+	ErrInvalidPageToken = errors.New("invalid page token")
+)
+
+// Record is an event as stored, together with how it reached the processor,
+// the client it belongs to, its priority, the tags given to it by triage rules,
+// how its sensitive fields were encrypted and when it was persisted.
+// PersistedAt is set by the store when the record is written and kept with
+// second precision. Events persisted before these fields were recorded have
+// zero values for them.
+type Record struct {
+	eventspec.Event
+	Metadata Metadata `json:"metadata"`
+	// Client is set by enrichment and nil for events that were not enriched.
+	Client *ClientMetadata `json:"client,omitempty" dynamodbav:",omitempty"`
+	// Priority is left empty for events that were not classified, which keeps
+	// them out of the priority index.
+	Priority eventspec.Priority `json:"priority,omitempty" dynamodbav:",omitempty"`
+	Tags     []string           `json:"tags,omitempty" dynamodbav:",omitempty"`
+	// Encryption describes the encrypted fields of Data, and is nil when no
+	// field was encrypted.
+	Encryption  *fieldcrypt.Envelope `json:"encryption,omitempty" dynamodbav:",omitempty"`
+	PersistedAt time.Time            `json:"persistedAt" dynamodbav:",unixtime"`
+}
+
+// Metadata describes the delivery of an event, taken from the message it
+// arrived in. SentAt to ReceivedAt is the time spent queued, and ReceivedAt to
+// PersistedAt the time spent processing.
+type Metadata struct {
+	MessageID string `json:"messageId"`
+	// ReceivedAt is when the processor received the message.
+	ReceivedAt time.Time `json:"receivedAt"`
+	// SentAt is when the message was sent to the queue.
+	SentAt time.Time `json:"sentAt"`
+	// ReceiveCount is how many times the message has been delivered, including
+	// this delivery.
+	ReceiveCount     int    `json:"receiveCount"`
+	ProcessorVersion string `json:"processorVersion"`
+}
+
+// ClientMetadata describes the client an event belongs to, copied from the
+// client registry when the event was processed.
+type ClientMetadata struct {
+	Name      string   `json:"name"`
+	Tier      string   `json:"tier"`
+	Endpoints []string `json:"endpoints,omitempty" dynamodbav:",omitempty"`
+}
+
+// Api persists events. Errors that will not succeed on retry are marked with
+// failure.Permanent; all other errors are retryable.
 type Api interface {
-	Persist(context.Context, eventspec.Event) error
+	Persist(context.Context, Record) error
+}
+
+// Reader reads persisted events back.
+type Reader interface {
+	// Get returns the event with the given ClientID and EventID, or
+	// ErrEventNotFound.
+	Get(ctx context.Context, clientID, eventID string) (Record, error)
+	// ListByClient returns a page of the client's events ordered by EventID,
+	// holding up to opts.Limit of the events that match opts.
+	ListByClient(ctx context.Context, clientID string, opts ListOptions) (Page, error)
+}
+
+// ListOptions narrows down and pages the events returned by ListByClient. The
+// zero value lists every event of the client.
+type ListOptions struct {
+	// Types limits the events to the given types. Empty means any type.
+	Types []string
+	// From and To limit the events to those persisted at or after From and
+	// before To. A zero time leaves that end of the range open.
+	From time.Time
+	To   time.Time
+	// Limit is the most records returned in the page. Zero means no limit
+	// beyond the store's own page size.
+	Limit int32
+	// PageToken continues a listing from the NextPageToken of a previous page.
+	PageToken string
+}
+
+// Page is one page of a listing. When a Limit is given, a page holds fewer
+// than Limit records only if it is the last. A full page may still be followed
+// by an empty one; the listing is complete only once NextPageToken is empty.
+type Page struct {
+	Records       []Record
+	NextPageToken string
+}
+
+// BatchApi is implemented by stores that can persist several events in one
+// round trip. As with Persist, the store sets PersistedAt. PersistBatch
+// returns a *BatchError identifying the events that could not be persisted;
+// events not listed in it were persisted.
+type BatchApi interface {
+	PersistBatch(context.Context, []Record) error
+}
+
+// BatchError holds the per-event errors of a batch, keyed by the index of the
+// event in the slice given to PersistBatch.
+type BatchError struct {
+	Errors map[int]error
+}
+
+func (e *BatchError) Error() string {
+	indexes := make([]int, 0, len(e.Errors))
+	for index := range e.Errors {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	messages := make([]string, len(indexes))
+	for i, index := range indexes {
+		messages[i] = fmt.Sprintf("event %d: %v", index, e.Errors[index])
+	}
+	return fmt.Sprintf("failed to persist %d events: %s", len(indexes), strings.Join(messages, "; "))
 }
@@ -0,0 +1,189 @@
+package eventstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/nivedita-verma/event-processor/internal/pkg/failure"
+)
+
+const (
+	// maxTransactWriteItems bounds the items written in one TransactWriteItems
+	// call. DynamoDB accepts 100, but no more than 4 MB in all, so chunks are
+	// kept small enough for events of up to 160 KB.
+	maxTransactWriteItems   = 25
+	defaultBatchAttempts    = 5
+	defaultBatchBaseBackoff = 50 * time.Millisecond
+)
+
+type batchItem struct {
+	index int
+	item  map[string]types.AttributeValue
+}
+
+// PersistBatch writes events with TransactWriteItems in chunks of 25, each
+// event on the same condition as Persist, so events already stored are never
+// overwritten, however concurrent the delivery that stored them. They are
+// reported as Persist reports them: ErrDuplicateEvent, or ErrConflictingEvent
+// with conflict detection. Duplicates within the batch itself are reported as
+// ErrDuplicateEvent and only the first occurrence is written.
+//
+// A failed condition cancels the whole transaction, which is then written
+// again without the events already stored. Transactions cancelled by
+// throttling or by conflicting transactions are retried with exponential
+// backoff.
+func (s *DynamoDBStore) PersistBatch(ctx context.Context, records []Record) error {
+	itemErrors := make(map[int]error)
+	seen := make(map[string]bool, len(records))
+	var pending []batchItem
+	for i, record := range records {
+		key := eventKey(record.ClientID, record.EventID)
+		if seen[key] {
+			itemErrors[i] = fmt.Errorf("event %s for client %s: %w", record.EventID, record.ClientID, ErrDuplicateEvent)
+			continue
+		}
+		seen[key] = true
+
+		item, err := s.marshal(s.stamp(record))
+		if err != nil {
+			itemErrors[i] = failure.Permanent(err)
+			continue
+		}
+		pending = append(pending, batchItem{index: i, item: item})
+	}
+
+	s.logger.Infof("Persisting batch of %d events to DynamoDB", len(pending))
+
+	for start := 0; start < len(pending); start += maxTransactWriteItems {
+		chunk := pending[start:min(start+maxTransactWriteItems, len(pending))]
+		for index, err := range s.writeChunk(ctx, records, chunk) {
+			itemErrors[index] = err
+		}
+	}
+
+	if len(itemErrors) > 0 {
+		return &BatchError{Errors: itemErrors}
+	}
+	return nil
+}
+
+// writeChunk writes up to 25 items in a transaction. It returns the errors of
+// unwritten items by their batch index.
+func (s *DynamoDBStore) writeChunk(ctx context.Context, records []Record, chunk []batchItem) map[int]error {
+	itemErrors := make(map[int]error)
+	for index, err := range s.retryUnprocessed(ctx, "TransactWriteItems", chunk, func(remaining []batchItem) ([]batchItem, error) {
+		return s.transactWrite(ctx, records, remaining, itemErrors)
+	}) {
+		itemErrors[index] = err
+	}
+	return itemErrors
+}
+
+// transactWrite writes items in a transaction, leaving out those found to be
+// stored already, whose errors are added to itemErrors. It returns the items
+// to be retried when the transaction was cancelled for any other reason.
+func (s *DynamoDBStore) transactWrite(ctx context.Context, records []Record, items []batchItem, itemErrors map[int]error) ([]batchItem, error) {
+	for len(items) > 0 {
+		transactItems := make([]types.TransactWriteItem, len(items))
+		for i, item := range items {
+			put := &types.Put{
+				TableName:           aws.String(s.tableName),
+				Item:                item.item,
+				ConditionExpression: aws.String(notPersistedCondition),
+			}
+			if s.detectConflicts {
+				put.ReturnValuesOnConditionCheckFailure = types.ReturnValuesOnConditionCheckFailureAllOld
+			}
+			transactItems[i] = types.TransactWriteItem{Put: put}
+		}
+
+		_, err := s.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: transactItems})
+		if err == nil {
+			return nil, nil
+		}
+		var cancelled *types.TransactionCanceledException
+		if !errors.As(err, &cancelled) {
+			return items, err
+		}
+		if len(cancelled.CancellationReasons) != len(items) {
+			return items, nil
+		}
+
+		// Reasons are given in the order of the items, with None for those
+		// that did not cause the cancellation
+		var unstored []batchItem
+		retry := false
+		for i, reason := range cancelled.CancellationReasons {
+			item := items[i]
+			switch aws.ToString(reason.Code) {
+			case "ConditionalCheckFailed":
+				itemErrors[item.index] = s.duplicateError(records[item.index], reason.Item)
+			case "ValidationError":
+				itemErrors[item.index] = failure.Permanent(fmt.Errorf("event %s for client %s: %s", records[item.index].EventID, records[item.index].ClientID, aws.ToString(reason.Message)))
+			case "None":
+				unstored = append(unstored, item)
+			default:
+				unstored = append(unstored, item)
+				retry = true
+			}
+		}
+		if retry {
+			return unstored, nil
+		}
+		items = unstored
+	}
+	return nil, nil
+}
+
+// retryUnprocessed sends items with send, which returns the items DynamoDB
+// left unprocessed or cancelled, retrying those with exponential backoff until none remain
+// or the attempts run out. It returns the errors of the items left by their
+// batch index.
+func (s *DynamoDBStore) retryUnprocessed(ctx context.Context, operation string, items []batchItem, send func([]batchItem) ([]batchItem, error)) map[int]error {
+	remaining := items
+	for attempt := 1; ; attempt++ {
+		var err error
+		remaining, err = send(remaining)
+		if err != nil {
+			return failAll(remaining, classifyError(err))
+		}
+		if len(remaining) == 0 {
+			return nil
+		}
+		if attempt >= s.batchAttempts {
+			return failAll(remaining, fmt.Errorf("%d items still unprocessed after %d attempts", len(remaining), attempt))
+		}
+
+		backoff := s.batchBackoff << (attempt - 1)
+		s.logger.Warnf("%d items unprocessed by %s, retrying in %s", len(remaining), operation, backoff)
+		select {
+		case <-ctx.Done():
+			return failAll(remaining, ctx.Err())
+		case <-time.After(backoff):
+		}
+	}
+}
+
+func failAll(items []batchItem, err error) map[int]error {
+	errs := make(map[int]error, len(items))
+	for _, item := range items {
+		errs[item.index] = err
+	}
+	return errs
+}
+
+func eventKey(clientID, eventID string) string {
+	return clientID + "\x00" + eventID
+}
+
+func stringAttribute(item map[string]types.AttributeValue, name string) string {
+	if value, ok := item[name].(*types.AttributeValueMemberS); ok {
+		return value.Value
+	}
+	return ""
+}
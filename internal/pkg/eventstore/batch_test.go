@@ -0,0 +1,236 @@
+package eventstore
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/nivedita-verma/event-processor/internal/pkg/failure"
+	"github.com/nivedita-verma/event-processor/pkg/eventspec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func batchOfRecords(count int) []Record {
+	records := make([]Record, count)
+	for i := range records {
+		records[i] = Record{Event: eventspec.Event{
+			EventID:  fmt.Sprintf("%d", i),
+			ClientID: "client-1",
+			Type:     "notification",
+			Data:     map[string]interface{}{"message": "hello"},
+		}}
+	}
+	return records
+}
+
+func transactItemCount(n int) interface{} {
+	return mock.MatchedBy(func(input *dynamodb.TransactWriteItemsInput) bool {
+		return len(input.TransactItems) == n
+	})
+}
+
+// cancelled returns the error of a transaction cancelled for the given
+// reasons, in the order of its items.
+func cancelled(reasons ...types.CancellationReason) error {
+	return &types.TransactionCanceledException{CancellationReasons: reasons}
+}
+
+func reason(code string) types.CancellationReason {
+	return types.CancellationReason{Code: aws.String(code)}
+}
+
+func Test_DynamoDBStore_PersistBatch(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+
+	t.Run("when the batch is larger than 25 events", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		store := NewDynamoDBStore(client, "testTable", logger)
+		client.On("TransactWriteItems", mock.Anything, transactItemCount(25)).Return(&dynamodb.TransactWriteItemsOutput{}, nil).Once()
+		client.On("TransactWriteItems", mock.Anything, transactItemCount(5)).Return(&dynamodb.TransactWriteItemsOutput{}, nil).Once()
+
+		err := store.PersistBatch(context.Background(), batchOfRecords(30))
+
+		t.Run("should write the events in chunks of 25", func(t *testing.T) {
+			assert.NoError(t, err)
+			client.AssertExpectations(t)
+		})
+
+		t.Run("should write every event on condition it is not stored", func(t *testing.T) {
+			input := client.Calls[0].Arguments.Get(1).(*dynamodb.TransactWriteItemsInput)
+			for _, item := range input.TransactItems {
+				assert.Equal(t, "testTable", *item.Put.TableName)
+				assert.Equal(t, notPersistedCondition, *item.Put.ConditionExpression)
+			}
+		})
+	})
+
+	t.Run("when an event of the batch is already persisted", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		store := NewDynamoDBStore(client, "testTable", logger)
+		client.On("TransactWriteItems", mock.Anything, transactItemCount(3)).Return(nil, cancelled(reason("None"), reason("ConditionalCheckFailed"), reason("None"))).Once()
+		client.On("TransactWriteItems", mock.Anything, transactItemCount(2)).Return(&dynamodb.TransactWriteItemsOutput{}, nil).Once()
+
+		err := store.PersistBatch(context.Background(), batchOfRecords(3))
+
+		t.Run("should write the other events without it", func(t *testing.T) {
+			client.AssertExpectations(t)
+			input := client.Calls[1].Arguments.Get(1).(*dynamodb.TransactWriteItemsInput)
+			assert.Equal(t, "0", stringAttribute(input.TransactItems[0].Put.Item, "EventID"))
+			assert.Equal(t, "2", stringAttribute(input.TransactItems[1].Put.Item, "EventID"))
+		})
+
+		t.Run("should report it as a duplicate", func(t *testing.T) {
+			var batchErr *BatchError
+			if assert.ErrorAs(t, err, &batchErr) {
+				assert.Len(t, batchErr.Errors, 1)
+				assert.ErrorIs(t, batchErr.Errors[1], ErrDuplicateEvent)
+			}
+		})
+	})
+
+	t.Run("when an event of the batch is already persisted with other data and conflicts are detected", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		store := NewDynamoDBStore(client, "testTable", logger, WithConflictDetection())
+		records := batchOfRecords(2)
+		stored := records[0]
+		stored.Data = map[string]interface{}{"message": "goodbye"}
+		existing, err := store.marshal(stored)
+		assert.NoError(t, err)
+		conflict := reason("ConditionalCheckFailed")
+		conflict.Item = existing
+		client.On("TransactWriteItems", mock.Anything, mock.MatchedBy(func(input *dynamodb.TransactWriteItemsInput) bool {
+			return len(input.TransactItems) == 2 && input.TransactItems[0].Put.ReturnValuesOnConditionCheckFailure == types.ReturnValuesOnConditionCheckFailureAllOld
+		})).Return(nil, cancelled(conflict, reason("None"))).Once()
+		client.On("TransactWriteItems", mock.Anything, transactItemCount(1)).Return(&dynamodb.TransactWriteItemsOutput{}, nil).Once()
+
+		err = store.PersistBatch(context.Background(), records)
+
+		t.Run("should report a permanent conflict for it", func(t *testing.T) {
+			client.AssertExpectations(t)
+			var batchErr *BatchError
+			if assert.ErrorAs(t, err, &batchErr) {
+				assert.ErrorIs(t, batchErr.Errors[0], ErrConflictingEvent)
+				assert.True(t, failure.IsPermanent(batchErr.Errors[0]))
+			}
+		})
+	})
+
+	t.Run("when the transaction is cancelled by a conflicting transaction", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		store := NewDynamoDBStore(client, "testTable", logger)
+		store.batchBackoff = 0
+		client.On("TransactWriteItems", mock.Anything, transactItemCount(2)).Return(nil, cancelled(reason("TransactionConflict"), reason("None"))).Once()
+		client.On("TransactWriteItems", mock.Anything, transactItemCount(2)).Return(&dynamodb.TransactWriteItemsOutput{}, nil).Once()
+
+		err := store.PersistBatch(context.Background(), batchOfRecords(2))
+
+		t.Run("should write the events again", func(t *testing.T) {
+			assert.NoError(t, err)
+			client.AssertExpectations(t)
+		})
+	})
+
+	t.Run("when the transaction keeps being cancelled", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		store := NewDynamoDBStore(client, "testTable", logger)
+		store.batchBackoff = 0
+		client.On("TransactWriteItems", mock.Anything, mock.Anything).Return(nil, cancelled(reason("ThrottlingError"), reason("None")))
+
+		err := store.PersistBatch(context.Background(), batchOfRecords(2))
+
+		t.Run("should give up after the maximum attempts", func(t *testing.T) {
+			client.AssertNumberOfCalls(t, "TransactWriteItems", defaultBatchAttempts)
+		})
+
+		t.Run("should report a retryable error for every event", func(t *testing.T) {
+			var batchErr *BatchError
+			if assert.ErrorAs(t, err, &batchErr) {
+				assert.Len(t, batchErr.Errors, 2)
+				assert.True(t, failure.IsRetryable(batchErr.Errors[0]))
+				assert.True(t, failure.IsRetryable(batchErr.Errors[1]))
+			}
+		})
+	})
+
+	t.Run("when an event is rejected by DynamoDB", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		store := NewDynamoDBStore(client, "testTable", logger)
+		client.On("TransactWriteItems", mock.Anything, transactItemCount(2)).Return(nil, cancelled(reason("None"), reason("ValidationError"))).Once()
+		client.On("TransactWriteItems", mock.Anything, transactItemCount(1)).Return(&dynamodb.TransactWriteItemsOutput{}, nil).Once()
+
+		err := store.PersistBatch(context.Background(), batchOfRecords(2))
+
+		t.Run("should write the other events and report a permanent error for it", func(t *testing.T) {
+			client.AssertExpectations(t)
+			var batchErr *BatchError
+			if assert.ErrorAs(t, err, &batchErr) {
+				assert.Len(t, batchErr.Errors, 1)
+				assert.True(t, failure.IsPermanent(batchErr.Errors[1]))
+			}
+		})
+	})
+
+	t.Run("when TransactWriteItems returns an error", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		store := NewDynamoDBStore(client, "testTable", logger)
+		client.On("TransactWriteItems", mock.Anything, mock.Anything).Return(nil, assert.AnError)
+
+		err := store.PersistBatch(context.Background(), batchOfRecords(2))
+
+		t.Run("should report the error for every event in the chunk", func(t *testing.T) {
+			var batchErr *BatchError
+			if assert.ErrorAs(t, err, &batchErr) {
+				assert.Equal(t, map[int]error{0: assert.AnError, 1: assert.AnError}, batchErr.Errors)
+			}
+		})
+	})
+
+	t.Run("when the batch contains the same event twice", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		store := NewDynamoDBStore(client, "testTable", logger)
+		records := append(batchOfRecords(2), batchOfRecords(1)...)
+		client.On("TransactWriteItems", mock.Anything, transactItemCount(2)).Return(&dynamodb.TransactWriteItemsOutput{}, nil)
+
+		err := store.PersistBatch(context.Background(), records)
+
+		t.Run("should write the first occurrence and report the rest as duplicates", func(t *testing.T) {
+			client.AssertExpectations(t)
+			var batchErr *BatchError
+			if assert.ErrorAs(t, err, &batchErr) {
+				assert.Len(t, batchErr.Errors, 1)
+				assert.ErrorIs(t, batchErr.Errors[2], ErrDuplicateEvent)
+			}
+		})
+	})
+
+	t.Run("when an event cannot be marshalled", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		store := NewDynamoDBStore(client, "testTable", logger)
+		store.marshal = func(interface{}) (map[string]types.AttributeValue, error) {
+			return nil, assert.AnError
+		}
+
+		err := store.PersistBatch(context.Background(), batchOfRecords(1))
+
+		t.Run("should report a permanent error for it", func(t *testing.T) {
+			client.AssertNotCalled(t, "TransactWriteItems", mock.Anything, mock.Anything)
+			var batchErr *BatchError
+			if assert.ErrorAs(t, err, &batchErr) {
+				assert.True(t, failure.IsPermanent(batchErr.Errors[0]))
+			}
+		})
+	})
+}
+
+func Test_BatchError_Error(t *testing.T) {
+	err := &BatchError{Errors: map[int]error{3: assert.AnError, 1: ErrDuplicateEvent}}
+
+	t.Run("should list the failed events in order", func(t *testing.T) {
+		assert.Equal(t, "failed to persist 2 events: event 1: event already persisted; event 3: "+assert.AnError.Error(), err.Error())
+	})
+}
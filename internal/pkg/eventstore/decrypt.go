@@ -0,0 +1,53 @@
+package eventstore
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nivedita-verma/event-processor/internal/pkg/fieldcrypt"
+)
+
+// DecryptingReader decrypts the encrypted fields of the records read by
+// another Reader. Records without encrypted fields are returned as read.
+type DecryptingReader struct {
+	reader    Reader
+	encryptor *fieldcrypt.Encryptor
+}
+
+func NewDecryptingReader(reader Reader, encryptor *fieldcrypt.Encryptor) *DecryptingReader {
+	return &DecryptingReader{reader: reader, encryptor: encryptor}
+}
+
+func (r *DecryptingReader) Get(ctx context.Context, clientID, eventID string) (Record, error) {
+	record, err := r.reader.Get(ctx, clientID, eventID)
+	if err != nil {
+		return Record{}, err
+	}
+	return r.decrypt(ctx, record)
+}
+
+func (r *DecryptingReader) ListByClient(ctx context.Context, clientID string, opts ListOptions) (Page, error) {
+	page, err := r.reader.ListByClient(ctx, clientID, opts)
+	if err != nil {
+		return Page{}, err
+	}
+	for i, record := range page.Records {
+		if page.Records[i], err = r.decrypt(ctx, record); err != nil {
+			return Page{}, err
+		}
+	}
+	return page, nil
+}
+
+func (r *DecryptingReader) decrypt(ctx context.Context, record Record) (Record, error) {
+	if record.Encryption == nil {
+		return record, nil
+	}
+	event, err := r.encryptor.Decrypt(ctx, record.Event, record.Encryption)
+	if err != nil {
+		return Record{}, fmt.Errorf("failed to decrypt event %s for client %s: %w", record.EventID, record.ClientID, err)
+	}
+	record.Event = event
+	record.Encryption = nil
+	return record, nil
+}
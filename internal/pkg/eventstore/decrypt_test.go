@@ -0,0 +1,71 @@
+package eventstore
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/nivedita-verma/event-processor/internal/pkg/fieldcrypt"
+	"github.com/nivedita-verma/event-processor/pkg/eventspec"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DecryptingReader(t *testing.T) {
+	ctx := context.Background()
+	provider, err := fieldcrypt.NewLocalKeyProvider("local-key", bytes.Repeat([]byte{1}, 32))
+	assert.NoError(t, err)
+	encryptor, err := fieldcrypt.New(provider, fieldcrypt.Policy{"transaction": {"data.cardNumber"}})
+	assert.NoError(t, err)
+
+	transaction := eventspec.Event{EventID: "1", ClientID: "client-1", Type: "transaction", Data: map[string]interface{}{"cardNumber": "4111111111111111"}}
+	notification := eventspec.Event{EventID: "2", ClientID: "client-1", Type: "notification", Data: map[string]interface{}{"message": "hello"}}
+
+	store := NewMemoryStore()
+	encrypted, envelope, err := encryptor.Encrypt(ctx, transaction)
+	assert.NoError(t, err)
+	assert.NoError(t, store.Persist(ctx, Record{Event: encrypted, Encryption: envelope}))
+	assert.NoError(t, store.Persist(ctx, Record{Event: notification}))
+
+	reader := NewDecryptingReader(store, encryptor)
+
+	t.Run("when an encrypted event is read", func(t *testing.T) {
+		record, err := reader.Get(ctx, "client-1", "1")
+
+		t.Run("should decrypt its fields", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Equal(t, transaction, record.Event)
+			assert.Nil(t, record.Encryption)
+		})
+	})
+
+	t.Run("when the client's events are listed", func(t *testing.T) {
+		page, err := reader.ListByClient(ctx, "client-1", ListOptions{})
+
+		t.Run("should decrypt the encrypted events only", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Len(t, page.Records, 2)
+			assert.Equal(t, transaction, page.Records[0].Event)
+			assert.Equal(t, notification, page.Records[1].Event)
+		})
+	})
+
+	t.Run("when the event is not found", func(t *testing.T) {
+		_, err := reader.Get(ctx, "client-1", "3")
+
+		t.Run("should return ErrEventNotFound", func(t *testing.T) {
+			assert.ErrorIs(t, err, ErrEventNotFound)
+		})
+	})
+
+	t.Run("when the fields cannot be decrypted", func(t *testing.T) {
+		tampered := encrypted
+		tampered.EventID = "4"
+		assert.NoError(t, store.Persist(ctx, Record{Event: tampered, Encryption: envelope}))
+
+		_, err := reader.Get(ctx, "client-1", "4")
+
+		t.Run("should return an error", func(t *testing.T) {
+			assert.ErrorContains(t, err, "failed to decrypt event 4 for client client-1")
+		})
+	})
+}
@@ -2,50 +2,169 @@ package eventstore
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+	"github.com/nivedita-verma/event-processor/internal/pkg/failure"
+	"github.com/nivedita-verma/event-processor/internal/pkg/redact"
 	"github.com/nivedita-verma/event-processor/pkg/eventspec"
 	"go.uber.org/zap"
 )
 
 type DynamoDBStore struct {
-	client    dynamoDBAPI
-	tableName string
-	marshal   func(interface{}) (map[string]types.AttributeValue, error)
-	logger    *zap.SugaredLogger
+	client          dynamoDBAPI
+	tableName       string
+	marshal         func(interface{}) (map[string]types.AttributeValue, error)
+	logger          *zap.SugaredLogger
+	detectConflicts bool
+	redactor        *redact.Redactor
+	batchAttempts   int
+	batchBackoff    time.Duration
+	now             func() time.Time
 }
 
 type dynamoDBAPI interface {
 	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error)
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
 }
 
-func NewDynamoDBStore(api dynamoDBAPI, tableName string, logger *zap.SugaredLogger) *DynamoDBStore {
-	return &DynamoDBStore{
-		client:    api,
-		tableName: tableName,
-		marshal:   attributevalue.MarshalMap,
-		logger:    logger,
+type StoreOption func(*DynamoDBStore)
+
+// WithConflictDetection makes Persist compare a duplicate with the stored event
+// and fail with ErrConflictingEvent when their type or data differ.
+func WithConflictDetection() StoreOption {
+	return func(s *DynamoDBStore) {
+		s.detectConflicts = true
+	}
+}
+
+// WithRedactor sets how sensitive data is masked in logged events. Defaults to
+// redact.DefaultPolicy.
+func WithRedactor(redactor *redact.Redactor) StoreOption {
+	return func(s *DynamoDBStore) {
+		s.redactor = redactor
+	}
+}
+
+func NewDynamoDBStore(api dynamoDBAPI, tableName string, logger *zap.SugaredLogger, opts ...StoreOption) *DynamoDBStore {
+	store := &DynamoDBStore{
+		client:        api,
+		tableName:     tableName,
+		marshal:       attributevalue.MarshalMap,
+		logger:        logger,
+		redactor:      redact.MustNew(redact.DefaultPolicy()),
+		batchAttempts: defaultBatchAttempts,
+		batchBackoff:  defaultBatchBaseBackoff,
+		now:           time.Now,
 	}
+	for _, opt := range opts {
+		opt(store)
+	}
+	return store
 }
 
-func (s *DynamoDBStore) Persist(ctx context.Context, event eventspec.Event) error {
-	item, err := s.marshal(event)
+// notPersistedCondition holds for an event not yet in the table.
+const notPersistedCondition = "attribute_not_exists(ClientID) AND attribute_not_exists(EventID)"
+
+// Persist writes the event only if no event with the same ClientID and EventID
+// exists, so redelivered messages do not overwrite the stored event or emit a
+// second stream record. Such duplicates return ErrDuplicateEvent.
+func (s *DynamoDBStore) Persist(ctx context.Context, record Record) error {
+	item, err := s.marshal(s.stamp(record))
 	if err != nil {
-		return err
+		return failure.Permanent(err)
 	}
 
-	s.logger.Infof("Persisting event to DynamoDB: %+v", event)
-	s.logger.Infof("Persisting item to DynamoDB: %+v", item)
+	s.logger.Infof("Persisting event to DynamoDB: %+v", s.redactor.Event(record.Event))
 
-	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
-		TableName: aws.String(s.tableName),
-		Item:      item,
-	})
+	input := &dynamodb.PutItemInput{
+		TableName:           aws.String(s.tableName),
+		Item:                item,
+		ConditionExpression: aws.String(notPersistedCondition),
+	}
+	if s.detectConflicts {
+		input.ReturnValuesOnConditionCheckFailure = types.ReturnValuesOnConditionCheckFailureAllOld
+	}
+
+	_, err = s.client.PutItem(ctx, input)
+	var conditionErr *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		return s.duplicateError(record, conditionErr.Item)
+	}
 	if err != nil {
-		return err
+		return classifyError(err)
 	}
 	return nil
 }
+
+// stamp sets the time the record is persisted.
+func (s *DynamoDBStore) stamp(record Record) Record {
+	record.PersistedAt = persistedAt(s.now())
+	return record
+}
+
+// persistedAt truncates t to the second precision PersistedAt is stored with.
+func persistedAt(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Second)
+}
+
+// duplicateError describes an attempt to persist an event that already exists.
+// When conflict detection is enabled the stored item is compared with the event.
+// Encrypted fields differ on every attempt, so only the type of a record with
+// encrypted fields is compared.
+func (s *DynamoDBStore) duplicateError(record Record, existingItem map[string]types.AttributeValue) error {
+	event := record.Event
+	if s.detectConflicts && existingItem != nil {
+		existing := eventspec.Event{}
+		if err := attributevalue.UnmarshalMap(existingItem, &existing); err != nil {
+			return fmt.Errorf("failed to unmarshal existing event %s: %w", event.EventID, err)
+		}
+		conflicting := existing.Type != event.Type
+		if record.Encryption == nil {
+			conflicting = !sameContent(existing, event)
+		}
+		if conflicting {
+			return failure.Permanent(fmt.Errorf("event %s for client %s: %w", event.EventID, event.ClientID, ErrConflictingEvent))
+		}
+	}
+	return fmt.Errorf("event %s for client %s: %w", event.EventID, event.ClientID, ErrDuplicateEvent)
+}
+
+// sameContent compares the type and data of two events. Data is normalised
+// through JSON so that numbers compare equal regardless of their Go type.
+func sameContent(a, b eventspec.Event) bool {
+	if a.Type != b.Type {
+		return false
+	}
+	aData, aErr := json.Marshal(a.Data)
+	bData, bErr := json.Marshal(b.Data)
+	if aErr != nil || bErr != nil {
+		return false
+	}
+	var aNormalised, bNormalised interface{}
+	if json.Unmarshal(aData, &aNormalised) != nil || json.Unmarshal(bData, &bNormalised) != nil {
+		return false
+	}
+	return reflect.DeepEqual(aNormalised, bNormalised)
+}
+
+// classifyError marks DynamoDB errors that will fail again on retry, such as an
+// item exceeding the size limit, as permanent. Throttling, timeouts and service
+// errors are left retryable.
+func classifyError(err error) error {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorCode() == "ValidationException" {
+		return failure.Permanent(err)
+	}
+	return err
+}
@@ -3,13 +3,22 @@ package eventstore
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+	"github.com/nivedita-verma/event-processor/internal/pkg/failure"
+	"github.com/nivedita-verma/event-processor/internal/pkg/fieldcrypt"
+	"github.com/nivedita-verma/event-processor/internal/pkg/redact"
 	"github.com/nivedita-verma/event-processor/pkg/eventspec"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func Test_NewDynamoDBStore(t *testing.T) {
@@ -38,9 +47,35 @@ func (m *mockDynamoDBClient) PutItem(ctx context.Context, input *dynamodb.PutIte
 	return args.Get(0).(*dynamodb.PutItemOutput), nil
 
 }
+
+func (m *mockDynamoDBClient) TransactWriteItems(ctx context.Context, input *dynamodb.TransactWriteItemsInput, opts ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	args := m.Called(ctx, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dynamodb.TransactWriteItemsOutput), nil
+}
+
+func (m *mockDynamoDBClient) GetItem(ctx context.Context, input *dynamodb.GetItemInput, opts ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	args := m.Called(ctx, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dynamodb.GetItemOutput), nil
+}
+
+func (m *mockDynamoDBClient) Query(ctx context.Context, input *dynamodb.QueryInput, opts ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	args := m.Called(ctx, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dynamodb.QueryOutput), nil
+}
+
 func Test_DynamoDBStore_Persist(t *testing.T) {
 	logger := zap.NewNop().Sugar()
 	defer logger.Sync()
+	persistedTime := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
 
 	t.Run("when error occurs during marshalling", func(t *testing.T) {
 		client := &mockDynamoDBClient{}
@@ -50,10 +85,11 @@ func Test_DynamoDBStore_Persist(t *testing.T) {
 			return nil, assert.AnError
 		}
 		event := eventspec.Event{}
-		err := store.Persist(context.Background(), event)
+		err := store.Persist(context.Background(), Record{Event: event})
 
-		t.Run("should return the marshalling error", func(t *testing.T) {
-			assert.Error(t, err)
+		t.Run("should return the marshalling error as permanent", func(t *testing.T) {
+			assert.ErrorIs(t, err, assert.AnError)
+			assert.True(t, failure.IsPermanent(err))
 		})
 	})
 
@@ -69,23 +105,54 @@ func Test_DynamoDBStore_Persist(t *testing.T) {
 				"key": "value",
 			},
 		}
-		item, err := store.marshal(event)
+		store.now = func() time.Time { return persistedTime }
+		item, err := store.marshal(Record{Event: event, PersistedAt: persistedTime})
 		assert.NoError(t, err)
 
 		client.On("PutItem", mock.Anything, &dynamodb.PutItemInput{
-			TableName: &tableName,
-			Item:      item,
+			TableName:           &tableName,
+			Item:                item,
+			ConditionExpression: aws.String("attribute_not_exists(ClientID) AND attribute_not_exists(EventID)"),
 		}).Return(nil, assert.AnError)
 
-		err = store.Persist(context.Background(), event)
+		err = store.Persist(context.Background(), Record{Event: event})
 
 		client.AssertExpectations(t)
-		t.Run("should return the PutItem error", func(t *testing.T) {
-			assert.Error(t, err)
+		t.Run("should return the PutItem error as retryable", func(t *testing.T) {
+			assert.ErrorIs(t, err, assert.AnError)
+			assert.True(t, failure.IsRetryable(err))
 		})
 
 	})
 
+	t.Run("when PutItem rejects the item as invalid", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		store := NewDynamoDBStore(client, "testTable", logger)
+		event := eventspec.Event{EventID: "1", ClientID: "client-1", Type: "TestEvent", Data: map[string]interface{}{"key": "value"}}
+
+		client.On("PutItem", mock.Anything, mock.Anything).Return(nil, &smithy.GenericAPIError{Code: "ValidationException", Message: "Item size has exceeded the maximum allowed size"})
+
+		err := store.Persist(context.Background(), Record{Event: event})
+
+		t.Run("should return a permanent error", func(t *testing.T) {
+			assert.True(t, failure.IsPermanent(err))
+		})
+	})
+
+	t.Run("when PutItem is throttled", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		store := NewDynamoDBStore(client, "testTable", logger)
+		event := eventspec.Event{EventID: "1", ClientID: "client-1", Type: "TestEvent", Data: map[string]interface{}{"key": "value"}}
+
+		client.On("PutItem", mock.Anything, mock.Anything).Return(nil, &types.ProvisionedThroughputExceededException{Message: aws.String("throttled")})
+
+		err := store.Persist(context.Background(), Record{Event: event})
+
+		t.Run("should return a retryable error", func(t *testing.T) {
+			assert.True(t, failure.IsRetryable(err))
+		})
+	})
+
 	t.Run("when PutItem is successful", func(t *testing.T) {
 		client := &mockDynamoDBClient{}
 		tableName := "testTable"
@@ -98,15 +165,17 @@ func Test_DynamoDBStore_Persist(t *testing.T) {
 				"key": "value",
 			},
 		}
-		item, err := store.marshal(event)
+		store.now = func() time.Time { return persistedTime }
+		item, err := store.marshal(Record{Event: event, PersistedAt: persistedTime})
 		assert.NoError(t, err)
 
 		client.On("PutItem", mock.Anything, &dynamodb.PutItemInput{
-			TableName: &tableName,
-			Item:      item,
+			TableName:           &tableName,
+			Item:                item,
+			ConditionExpression: aws.String("attribute_not_exists(ClientID) AND attribute_not_exists(EventID)"),
 		}).Return(&dynamodb.PutItemOutput{}, nil)
 
-		err = store.Persist(context.Background(), event)
+		err = store.Persist(context.Background(), Record{Event: event})
 
 		client.AssertExpectations(t)
 
@@ -115,4 +184,141 @@ func Test_DynamoDBStore_Persist(t *testing.T) {
 		})
 
 	})
+
+	t.Run("when the event has already been persisted", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		store := NewDynamoDBStore(client, "testTable", logger)
+		event := eventspec.Event{EventID: "1", ClientID: "client-1", Type: "notification", Data: map[string]interface{}{"message": "hello"}}
+
+		client.On("PutItem", mock.Anything, mock.Anything).Return(nil, &types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")})
+
+		err := store.Persist(context.Background(), Record{Event: event})
+
+		t.Run("should return ErrDuplicateEvent", func(t *testing.T) {
+			assert.ErrorIs(t, err, ErrDuplicateEvent)
+		})
+	})
+}
+
+func Test_DynamoDBStore_Persist_WithConflictDetection(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	event := eventspec.Event{EventID: "1", ClientID: "client-1", Type: "transaction", Data: map[string]interface{}{"amount": 10, "currency": "GBP"}}
+
+	conditionFailed := func(t *testing.T, stored eventspec.Event) error {
+		item, err := attributevalue.MarshalMap(stored)
+		assert.NoError(t, err)
+		return &types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed"), Item: item}
+	}
+
+	t.Run("should ask DynamoDB to return the stored item on conflict", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		store := NewDynamoDBStore(client, "testTable", logger, WithConflictDetection())
+		client.On("PutItem", mock.Anything, mock.MatchedBy(func(input *dynamodb.PutItemInput) bool {
+			return input.ReturnValuesOnConditionCheckFailure == types.ReturnValuesOnConditionCheckFailureAllOld
+		})).Return(&dynamodb.PutItemOutput{}, nil)
+
+		assert.NoError(t, store.Persist(context.Background(), Record{Event: event}))
+		client.AssertExpectations(t)
+	})
+
+	t.Run("when the stored event has the same content", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		store := NewDynamoDBStore(client, "testTable", logger, WithConflictDetection())
+		client.On("PutItem", mock.Anything, mock.Anything).Return(nil, conditionFailed(t, event))
+
+		err := store.Persist(context.Background(), Record{Event: event})
+
+		t.Run("should return ErrDuplicateEvent", func(t *testing.T) {
+			assert.ErrorIs(t, err, ErrDuplicateEvent)
+			assert.NotErrorIs(t, err, ErrConflictingEvent)
+		})
+	})
+
+	t.Run("when the stored event has different data", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		store := NewDynamoDBStore(client, "testTable", logger, WithConflictDetection())
+		stored := event
+		stored.Data = map[string]interface{}{"amount": 99, "currency": "GBP"}
+		client.On("PutItem", mock.Anything, mock.Anything).Return(nil, conditionFailed(t, stored))
+
+		err := store.Persist(context.Background(), Record{Event: event})
+
+		t.Run("should return a permanent ErrConflictingEvent", func(t *testing.T) {
+			assert.ErrorIs(t, err, ErrConflictingEvent)
+			assert.True(t, failure.IsPermanent(err))
+		})
+	})
+
+	t.Run("when the event has encrypted fields and the stored ciphertext differs", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		store := NewDynamoDBStore(client, "testTable", logger, WithConflictDetection())
+		stored := event
+		stored.Data = map[string]interface{}{"amount": 10, "currency": "ciphertext-1"}
+		client.On("PutItem", mock.Anything, mock.Anything).Return(nil, conditionFailed(t, stored))
+
+		encrypted := event
+		encrypted.Data = map[string]interface{}{"amount": 10, "currency": "ciphertext-2"}
+		err := store.Persist(context.Background(), Record{Event: encrypted, Encryption: &fieldcrypt.Envelope{KeyID: "key", Paths: []string{"data.currency"}}})
+
+		t.Run("should return ErrDuplicateEvent", func(t *testing.T) {
+			assert.ErrorIs(t, err, ErrDuplicateEvent)
+			assert.NotErrorIs(t, err, ErrConflictingEvent)
+		})
+	})
+}
+
+func Test_DynamoDBStore_Persist_Priority(t *testing.T) {
+	event := eventspec.Event{EventID: "1", ClientID: "client-1", Type: "monitoringAlert", Data: map[string]interface{}{"severity": "critical"}}
+
+	t.Run("when the record has a priority", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		store := NewDynamoDBStore(client, "testTable", zap.NewNop().Sugar())
+		client.On("PutItem", mock.Anything, mock.MatchedBy(func(input *dynamodb.PutItemInput) bool {
+			return assert.ObjectsAreEqual(&types.AttributeValueMemberS{Value: "critical"}, input.Item["Priority"])
+		})).Return(&dynamodb.PutItemOutput{}, nil)
+
+		err := store.Persist(context.Background(), Record{Event: event, Priority: eventspec.PriorityCritical})
+
+		t.Run("should store it as an attribute", func(t *testing.T) {
+			assert.NoError(t, err)
+			client.AssertExpectations(t)
+		})
+	})
+
+	t.Run("when the record has no priority", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		store := NewDynamoDBStore(client, "testTable", zap.NewNop().Sugar())
+		client.On("PutItem", mock.Anything, mock.MatchedBy(func(input *dynamodb.PutItemInput) bool {
+			_, ok := input.Item["Priority"]
+			return !ok
+		})).Return(&dynamodb.PutItemOutput{}, nil)
+
+		err := store.Persist(context.Background(), Record{Event: event})
+
+		t.Run("should leave the attribute out so the event is not indexed", func(t *testing.T) {
+			assert.NoError(t, err)
+			client.AssertExpectations(t)
+		})
+	})
+}
+
+func Test_DynamoDBStore_Persist_RedactsLogs(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	event := eventspec.Event{EventID: "1", ClientID: "client-1", Type: "transaction", Data: map[string]interface{}{"amount": 10.0, "cardNumber": "4111111111111111"}}
+	client := &mockDynamoDBClient{}
+	client.On("PutItem", mock.Anything, mock.MatchedBy(func(input *dynamodb.PutItemInput) bool {
+		return assert.ObjectsAreEqual(&types.AttributeValueMemberS{Value: "4111111111111111"}, input.Item["Data"].(*types.AttributeValueMemberM).Value["cardNumber"])
+	})).Return(&dynamodb.PutItemOutput{}, nil)
+	store := NewDynamoDBStore(client, "testTable", zap.New(core).Sugar())
+
+	err := store.Persist(context.Background(), Record{Event: event})
+
+	t.Run("should store the event whole but log it redacted", func(t *testing.T) {
+		assert.NoError(t, err)
+		client.AssertExpectations(t)
+		for _, entry := range logs.All() {
+			assert.NotContains(t, entry.Message, "4111111111111111")
+		}
+		assert.Contains(t, logs.All()[0].Message, redact.Mask)
+	})
 }
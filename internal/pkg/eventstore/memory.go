@@ -0,0 +1,119 @@
+package eventstore
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps events in memory. It is intended for tests and local runs
+// and behaves like DynamoDBStore, including rejecting duplicates.
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string]Record
+	now     func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		records: make(map[string]Record),
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryStore) Persist(_ context.Context, record Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := eventKey(record.ClientID, record.EventID)
+	if _, ok := s.records[key]; ok {
+		return fmt.Errorf("event %s for client %s: %w", record.EventID, record.ClientID, ErrDuplicateEvent)
+	}
+	record.PersistedAt = persistedAt(s.now())
+	s.records[key] = record
+	return nil
+}
+
+func (s *MemoryStore) Get(_ context.Context, clientID, eventID string) (Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.records[eventKey(clientID, eventID)]
+	if !ok {
+		return Record{}, fmt.Errorf("event %s for client %s: %w", eventID, clientID, ErrEventNotFound)
+	}
+	return record, nil
+}
+
+// ListByClient returns the client's events in EventID order.
+func (s *MemoryStore) ListByClient(_ context.Context, clientID string, opts ListOptions) (Page, error) {
+	after := ""
+	if opts.PageToken != "" {
+		eventID, err := decodePageToken(opts.PageToken)
+		if err != nil {
+			return Page{}, err
+		}
+		after = eventID
+	}
+
+	s.mu.Lock()
+	var matched []Record
+	for _, record := range s.records {
+		if record.ClientID == clientID && record.EventID > after && matchesListOptions(record, opts) {
+			matched = append(matched, record)
+		}
+	}
+	s.mu.Unlock()
+	sort.Slice(matched, func(i, j int) bool {
+		return matched[i].EventID < matched[j].EventID
+	})
+
+	page := Page{Records: matched}
+	if opts.Limit > 0 && len(matched) > int(opts.Limit) {
+		page.Records = matched[:opts.Limit]
+		page.NextPageToken = encodePageToken(page.Records[len(page.Records)-1].EventID)
+	}
+	if page.Records == nil {
+		page.Records = []Record{}
+	}
+	return page, nil
+}
+
+func matchesListOptions(record Record, opts ListOptions) bool {
+	if len(opts.Types) > 0 && !slices.Contains(opts.Types, record.Type) {
+		return false
+	}
+	if !opts.From.IsZero() && record.PersistedAt.Before(opts.From.Truncate(time.Second)) {
+		return false
+	}
+	if !opts.To.IsZero() && !record.PersistedAt.Before(opts.To.Truncate(time.Second)) {
+		return false
+	}
+	return true
+}
+
+// MemoryQuarantine keeps quarantined messages in memory. It is intended for
+// tests and local runs.
+type MemoryQuarantine struct {
+	mu       sync.Mutex
+	messages []QuarantinedMessage
+}
+
+func NewMemoryQuarantine() *MemoryQuarantine {
+	return &MemoryQuarantine{}
+}
+
+func (q *MemoryQuarantine) Quarantine(_ context.Context, message QuarantinedMessage) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.messages = append(q.messages, message)
+	return nil
+}
+
+// Messages returns a copy of the quarantined messages in the order received.
+func (q *MemoryQuarantine) Messages() []QuarantinedMessage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]QuarantinedMessage(nil), q.messages...)
+}
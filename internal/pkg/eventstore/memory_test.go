@@ -0,0 +1,118 @@
+package eventstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nivedita-verma/event-processor/pkg/eventspec"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MemoryQuarantine(t *testing.T) {
+	quarantine := NewMemoryQuarantine()
+
+	assert.NoError(t, quarantine.Quarantine(context.Background(), QuarantinedMessage{MessageID: "msg-1"}))
+	assert.NoError(t, quarantine.Quarantine(context.Background(), QuarantinedMessage{MessageID: "msg-2"}))
+
+	t.Run("should return messages in the order received", func(t *testing.T) {
+		assert.Equal(t, []QuarantinedMessage{{MessageID: "msg-1"}, {MessageID: "msg-2"}}, quarantine.Messages())
+	})
+
+	t.Run("should return a copy", func(t *testing.T) {
+		messages := quarantine.Messages()
+		messages[0].MessageID = "changed"
+		assert.Equal(t, "msg-1", quarantine.Messages()[0].MessageID)
+	})
+}
+
+func Test_MemoryStore(t *testing.T) {
+	ctx := context.Background()
+	day := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	persist := func(at time.Time, event eventspec.Event) {
+		store.now = func() time.Time { return at }
+		assert.NoError(t, store.Persist(ctx, Record{Event: event}))
+	}
+	persist(day, eventspec.Event{EventID: "1", ClientID: "client-1", Type: "notification"})
+	persist(day.Add(time.Hour), eventspec.Event{EventID: "3", ClientID: "client-1", Type: "transaction"})
+	persist(day.Add(2*time.Hour), eventspec.Event{EventID: "2", ClientID: "client-1", Type: "notification"})
+	persist(day, eventspec.Event{EventID: "1", ClientID: "client-2", Type: "notification"})
+
+	eventIDs := func(page Page) []string {
+		ids := []string{}
+		for _, record := range page.Records {
+			ids = append(ids, record.EventID)
+		}
+		return ids
+	}
+
+	t.Run("when an event is persisted twice", func(t *testing.T) {
+		err := store.Persist(ctx, Record{Event: eventspec.Event{EventID: "1", ClientID: "client-1", Type: "notification"}})
+
+		t.Run("should return ErrDuplicateEvent", func(t *testing.T) {
+			assert.ErrorIs(t, err, ErrDuplicateEvent)
+		})
+	})
+
+	t.Run("when getting a stored event", func(t *testing.T) {
+		record, err := store.Get(ctx, "client-1", "3")
+
+		t.Run("should return it with the time it was persisted", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Equal(t, Record{Event: eventspec.Event{EventID: "3", ClientID: "client-1", Type: "transaction"}, PersistedAt: day.Add(time.Hour)}, record)
+		})
+	})
+
+	t.Run("when getting an unknown event", func(t *testing.T) {
+		_, err := store.Get(ctx, "client-2", "3")
+
+		t.Run("should return ErrEventNotFound", func(t *testing.T) {
+			assert.ErrorIs(t, err, ErrEventNotFound)
+		})
+	})
+
+	t.Run("when listing without options", func(t *testing.T) {
+		page, err := store.ListByClient(ctx, "client-1", ListOptions{})
+
+		t.Run("should return the client's events in EventID order", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"1", "2", "3"}, eventIDs(page))
+			assert.Empty(t, page.NextPageToken)
+		})
+	})
+
+	t.Run("when listing by type and time range", func(t *testing.T) {
+		page, err := store.ListByClient(ctx, "client-1", ListOptions{
+			Types: []string{"notification"},
+			From:  day.Add(time.Minute),
+			To:    day.Add(3 * time.Hour),
+		})
+
+		t.Run("should return only the matching events", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"2"}, eventIDs(page))
+		})
+	})
+
+	t.Run("when listing page by page", func(t *testing.T) {
+		first, err := store.ListByClient(ctx, "client-1", ListOptions{Limit: 2})
+		assert.NoError(t, err)
+		second, err := store.ListByClient(ctx, "client-1", ListOptions{Limit: 2, PageToken: first.NextPageToken})
+		assert.NoError(t, err)
+
+		t.Run("should continue from the page token", func(t *testing.T) {
+			assert.Equal(t, []string{"1", "2"}, eventIDs(first))
+			assert.Equal(t, []string{"3"}, eventIDs(second))
+			assert.Empty(t, second.NextPageToken)
+		})
+	})
+
+	t.Run("when the page token is invalid", func(t *testing.T) {
+		_, err := store.ListByClient(ctx, "client-1", ListOptions{PageToken: "not a token!"})
+
+		t.Run("should return ErrInvalidPageToken", func(t *testing.T) {
+			assert.ErrorIs(t, err, ErrInvalidPageToken)
+		})
+	})
+}
@@ -0,0 +1,64 @@
+package eventstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"go.uber.org/zap"
+)
+
+// QuarantinedMessage records a message that was acknowledged without being
+// persisted because it failed permanently, together with why it was rejected.
+// ClientID and EventID are best effort and empty when the body could not be
+// decoded.
+type QuarantinedMessage struct {
+	MessageID      string
+	ClientID       string `dynamodbav:",omitempty"`
+	EventID        string `dynamodbav:",omitempty"`
+	Body           string
+	ReceiveCount   int
+	RejectionCode  string
+	RejectionCodes []string `dynamodbav:",omitempty"`
+	Reason         string
+	QuarantinedAt  time.Time
+}
+
+// Quarantine stores permanently failed messages so they can be inspected later.
+type Quarantine interface {
+	Quarantine(context.Context, QuarantinedMessage) error
+}
+
+type DynamoDBQuarantine struct {
+	client    dynamoDBAPI
+	tableName string
+	marshal   func(interface{}) (map[string]types.AttributeValue, error)
+	logger    *zap.SugaredLogger
+}
+
+func NewDynamoDBQuarantine(api dynamoDBAPI, tableName string, logger *zap.SugaredLogger) *DynamoDBQuarantine {
+	return &DynamoDBQuarantine{
+		client:    api,
+		tableName: tableName,
+		marshal:   attributevalue.MarshalMap,
+		logger:    logger,
+	}
+}
+
+func (q *DynamoDBQuarantine) Quarantine(ctx context.Context, message QuarantinedMessage) error {
+	item, err := q.marshal(message)
+	if err != nil {
+		return err
+	}
+
+	q.logger.Infof("Quarantining message ID %s with rejection code %s", message.MessageID, message.RejectionCode)
+
+	_, err = q.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(q.tableName),
+		Item:      item,
+	})
+	return err
+}
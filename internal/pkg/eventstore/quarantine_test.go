@@ -0,0 +1,104 @@
+package eventstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func Test_NewDynamoDBQuarantine(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	client := &dynamodb.Client{}
+	quarantine := NewDynamoDBQuarantine(client, "quarantineTable", logger)
+
+	assert.NotNil(t, quarantine)
+	assert.Equal(t, client, quarantine.client)
+	assert.Equal(t, "quarantineTable", quarantine.tableName)
+	assert.Equal(t, logger, quarantine.logger)
+}
+
+func Test_DynamoDBQuarantine_Quarantine(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	message := QuarantinedMessage{
+		MessageID:      "msg-1",
+		ClientID:       "client-3",
+		EventID:        "1",
+		Body:           `{"eventId":"1","clientId":"client-3","type":"transaction","data":{}}`,
+		ReceiveCount:   1,
+		RejectionCode:  "MISSING_FIELD",
+		RejectionCodes: []string{"MISSING_FIELD", "MISSING_FIELD"},
+		Reason:         "MISSING_FIELD /data/amount: missing required property",
+		QuarantinedAt:  time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	t.Run("when error occurs during marshalling", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		quarantine := NewDynamoDBQuarantine(client, "quarantineTable", logger)
+		quarantine.marshal = func(interface{}) (map[string]types.AttributeValue, error) {
+			return nil, assert.AnError
+		}
+
+		err := quarantine.Quarantine(context.Background(), message)
+
+		t.Run("should return the marshalling error", func(t *testing.T) {
+			assert.ErrorIs(t, err, assert.AnError)
+		})
+	})
+
+	t.Run("when PutItem returns an error", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		quarantine := NewDynamoDBQuarantine(client, "quarantineTable", logger)
+		client.On("PutItem", mock.Anything, mock.Anything).Return(nil, assert.AnError)
+
+		err := quarantine.Quarantine(context.Background(), message)
+
+		t.Run("should return the PutItem error", func(t *testing.T) {
+			assert.ErrorIs(t, err, assert.AnError)
+		})
+	})
+
+	t.Run("when PutItem is successful", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		tableName := "quarantineTable"
+		quarantine := NewDynamoDBQuarantine(client, tableName, logger)
+		item, err := quarantine.marshal(message)
+		assert.NoError(t, err)
+
+		client.On("PutItem", mock.Anything, &dynamodb.PutItemInput{
+			TableName: &tableName,
+			Item:      item,
+		}).Return(&dynamodb.PutItemOutput{}, nil)
+
+		err = quarantine.Quarantine(context.Background(), message)
+
+		client.AssertExpectations(t)
+
+		t.Run("should complete without error", func(t *testing.T) {
+			assert.NoError(t, err)
+		})
+
+		t.Run("should store the rejection details", func(t *testing.T) {
+			assert.Equal(t, &types.AttributeValueMemberS{Value: "msg-1"}, item["MessageID"])
+			assert.Equal(t, &types.AttributeValueMemberS{Value: "MISSING_FIELD"}, item["RejectionCode"])
+			assert.Equal(t, &types.AttributeValueMemberN{Value: "1"}, item["ReceiveCount"])
+			assert.Equal(t, &types.AttributeValueMemberS{Value: "2025-01-02T03:04:05Z"}, item["QuarantinedAt"])
+		})
+	})
+
+	t.Run("when the client is unknown", func(t *testing.T) {
+		quarantine := NewDynamoDBQuarantine(&mockDynamoDBClient{}, "quarantineTable", logger)
+
+		item, err := quarantine.marshal(QuarantinedMessage{MessageID: "msg-1", Body: "not json"})
+		assert.NoError(t, err)
+
+		t.Run("should omit the client so it stays out of the client index", func(t *testing.T) {
+			assert.NotContains(t, item, "ClientID")
+		})
+	})
+}
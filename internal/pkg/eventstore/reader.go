@@ -0,0 +1,132 @@
+package eventstore
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Get returns the event with the given ClientID and EventID, or ErrEventNotFound.
+func (s *DynamoDBStore) Get(ctx context.Context, clientID, eventID string) (Record, error) {
+	output, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.tableName),
+		Key:       keyAttributes(clientID, eventID),
+	})
+	if err != nil {
+		return Record{}, err
+	}
+	if output.Item == nil {
+		return Record{}, fmt.Errorf("event %s for client %s: %w", eventID, clientID, ErrEventNotFound)
+	}
+	return unmarshalRecord(output.Item)
+}
+
+// ListByClient queries the client's partition in EventID order. Type and time
+// filters are applied by DynamoDB after reading, so when a Limit is given the
+// partition is queried again until Limit records match or it is exhausted.
+func (s *DynamoDBStore) ListByClient(ctx context.Context, clientID string, opts ListOptions) (Page, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(s.tableName),
+		KeyConditionExpression: aws.String("ClientID = :clientId"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":clientId": &types.AttributeValueMemberS{Value: clientID},
+		},
+	}
+	if opts.Limit > 0 {
+		input.Limit = aws.Int32(opts.Limit)
+	}
+	if opts.PageToken != "" {
+		eventID, err := decodePageToken(opts.PageToken)
+		if err != nil {
+			return Page{}, err
+		}
+		input.ExclusiveStartKey = keyAttributes(clientID, eventID)
+	}
+
+	var filters []string
+	if len(opts.Types) > 0 {
+		placeholders := make([]string, len(opts.Types))
+		for i, eventType := range opts.Types {
+			placeholders[i] = fmt.Sprintf(":type%d", i)
+			input.ExpressionAttributeValues[placeholders[i]] = &types.AttributeValueMemberS{Value: eventType}
+		}
+		// Type is a reserved word in DynamoDB expressions
+		input.ExpressionAttributeNames = map[string]string{"#type": "Type"}
+		filters = append(filters, fmt.Sprintf("#type IN (%s)", strings.Join(placeholders, ", ")))
+	}
+	if !opts.From.IsZero() {
+		input.ExpressionAttributeValues[":from"] = &types.AttributeValueMemberN{Value: strconv.FormatInt(opts.From.Unix(), 10)}
+		filters = append(filters, "PersistedAt >= :from")
+	}
+	if !opts.To.IsZero() {
+		input.ExpressionAttributeValues[":to"] = &types.AttributeValueMemberN{Value: strconv.FormatInt(opts.To.Unix(), 10)}
+		filters = append(filters, "PersistedAt < :to")
+	}
+	if len(filters) > 0 {
+		input.FilterExpression = aws.String(strings.Join(filters, " AND "))
+	}
+
+	page := Page{Records: []Record{}}
+	for {
+		output, err := s.client.Query(ctx, input)
+		if err != nil {
+			return Page{}, err
+		}
+		for _, item := range output.Items {
+			record, err := unmarshalRecord(item)
+			if err != nil {
+				return Page{}, err
+			}
+			page.Records = append(page.Records, record)
+		}
+		if output.LastEvaluatedKey == nil {
+			return page, nil
+		}
+		remaining := opts.Limit - int32(len(page.Records))
+		if opts.Limit == 0 || remaining <= 0 {
+			page.NextPageToken = encodePageToken(stringAttribute(output.LastEvaluatedKey, "EventID"))
+			return page, nil
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
+		input.Limit = aws.Int32(remaining)
+	}
+}
+
+func unmarshalRecord(item map[string]types.AttributeValue) (Record, error) {
+	record := Record{}
+	if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+		return Record{}, fmt.Errorf("failed to unmarshal event %s: %w", stringAttribute(item, "EventID"), err)
+	}
+	if !record.PersistedAt.IsZero() {
+		record.PersistedAt = record.PersistedAt.UTC()
+	}
+	return record, nil
+}
+
+func keyAttributes(clientID, eventID string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"ClientID": &types.AttributeValueMemberS{Value: clientID},
+		"EventID":  &types.AttributeValueMemberS{Value: eventID},
+	}
+}
+
+// encodePageToken makes the EventID a listing stopped at opaque to callers.
+// The ClientID is not part of the token as it is given with every call.
+func encodePageToken(eventID string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(eventID))
+}
+
+func decodePageToken(token string) (string, error) {
+	eventID, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(eventID) == 0 {
+		return "", fmt.Errorf("%w: %q", ErrInvalidPageToken, token)
+	}
+	return string(eventID), nil
+}
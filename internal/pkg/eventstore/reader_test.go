@@ -0,0 +1,249 @@
+package eventstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/nivedita-verma/event-processor/pkg/eventspec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func storedItem(t *testing.T, record Record) map[string]types.AttributeValue {
+	item, err := attributevalue.MarshalMap(record)
+	assert.NoError(t, err)
+	return item
+}
+
+func Test_DynamoDBStore_Persist_RecordsPersistedAt(t *testing.T) {
+	client := &mockDynamoDBClient{}
+	store := NewDynamoDBStore(client, "testTable", zap.NewNop().Sugar())
+	store.now = func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 600, time.UTC) }
+	event := eventspec.Event{EventID: "1", ClientID: "client-1", Type: "notification", Data: map[string]interface{}{"message": "hello"}}
+
+	client.On("PutItem", mock.Anything, mock.MatchedBy(func(input *dynamodb.PutItemInput) bool {
+		return assert.ObjectsAreEqual(&types.AttributeValueMemberN{Value: "1767323045"}, input.Item["PersistedAt"]) &&
+			assert.ObjectsAreEqual(&types.AttributeValueMemberS{Value: "client-1"}, input.Item["ClientID"])
+	})).Return(&dynamodb.PutItemOutput{}, nil)
+
+	err := store.Persist(context.Background(), Record{Event: event})
+
+	t.Run("should store the event alongside the time it was persisted in epoch seconds", func(t *testing.T) {
+		assert.NoError(t, err)
+		client.AssertExpectations(t)
+	})
+}
+
+func Test_DynamoDBStore_Get(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	record := Record{
+		Event: eventspec.Event{EventID: "1", ClientID: "client-1", Type: "notification", Data: map[string]interface{}{"message": "hello"}},
+		Metadata: Metadata{
+			MessageID:        "msg-1",
+			ReceivedAt:       time.Date(2026, 1, 2, 3, 4, 4, 250000000, time.UTC),
+			SentAt:           time.Date(2026, 1, 2, 3, 4, 1, 500000000, time.UTC),
+			ReceiveCount:     2,
+			ProcessorVersion: "v1.2.3",
+		},
+		Client:      &ClientMetadata{Name: "Client One", Tier: "gold", Endpoints: []string{"https://example.com/events"}},
+		Tags:        []string{"large"},
+		PersistedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	expectedInput := &dynamodb.GetItemInput{
+		TableName: aws.String("testTable"),
+		Key: map[string]types.AttributeValue{
+			"ClientID": &types.AttributeValueMemberS{Value: "client-1"},
+			"EventID":  &types.AttributeValueMemberS{Value: "1"},
+		},
+	}
+
+	t.Run("when the event exists", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		store := NewDynamoDBStore(client, "testTable", logger)
+		client.On("GetItem", mock.Anything, expectedInput).Return(&dynamodb.GetItemOutput{Item: storedItem(t, record)}, nil)
+
+		got, err := store.Get(context.Background(), "client-1", "1")
+
+		t.Run("should return the stored record with its metadata", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Equal(t, record, got)
+		})
+	})
+
+	t.Run("when the event does not exist", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		store := NewDynamoDBStore(client, "testTable", logger)
+		client.On("GetItem", mock.Anything, expectedInput).Return(&dynamodb.GetItemOutput{}, nil)
+
+		_, err := store.Get(context.Background(), "client-1", "1")
+
+		t.Run("should return ErrEventNotFound", func(t *testing.T) {
+			assert.ErrorIs(t, err, ErrEventNotFound)
+		})
+	})
+
+	t.Run("when GetItem returns an error", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		store := NewDynamoDBStore(client, "testTable", logger)
+		client.On("GetItem", mock.Anything, expectedInput).Return(nil, assert.AnError)
+
+		_, err := store.Get(context.Background(), "client-1", "1")
+
+		t.Run("should return the error", func(t *testing.T) {
+			assert.ErrorIs(t, err, assert.AnError)
+		})
+	})
+}
+
+func Test_DynamoDBStore_ListByClient(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	record := Record{
+		Event:       eventspec.Event{EventID: "1", ClientID: "client-1", Type: "notification", Data: map[string]interface{}{"message": "hello"}},
+		PersistedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	t.Run("when no options are given", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		store := NewDynamoDBStore(client, "testTable", logger)
+		client.On("Query", mock.Anything, &dynamodb.QueryInput{
+			TableName:              aws.String("testTable"),
+			KeyConditionExpression: aws.String("ClientID = :clientId"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":clientId": &types.AttributeValueMemberS{Value: "client-1"},
+			},
+		}).Return(&dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{storedItem(t, record)}}, nil)
+
+		page, err := store.ListByClient(context.Background(), "client-1", ListOptions{})
+
+		t.Run("should query the client's partition and return every record", func(t *testing.T) {
+			assert.NoError(t, err)
+			client.AssertExpectations(t)
+			assert.Equal(t, Page{Records: []Record{record}}, page)
+		})
+	})
+
+	t.Run("when filters and a page token are given", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		store := NewDynamoDBStore(client, "testTable", logger)
+		client.On("Query", mock.Anything, &dynamodb.QueryInput{
+			TableName:              aws.String("testTable"),
+			KeyConditionExpression: aws.String("ClientID = :clientId"),
+			FilterExpression:       aws.String("#type IN (:type0, :type1) AND PersistedAt >= :from AND PersistedAt < :to"),
+			ExpressionAttributeNames: map[string]string{
+				"#type": "Type",
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":clientId": &types.AttributeValueMemberS{Value: "client-1"},
+				":type0":    &types.AttributeValueMemberS{Value: "notification"},
+				":type1":    &types.AttributeValueMemberS{Value: "transaction"},
+				":from":     &types.AttributeValueMemberN{Value: "1767225600"},
+				":to":       &types.AttributeValueMemberN{Value: "1767312000"},
+			},
+			ExclusiveStartKey: map[string]types.AttributeValue{
+				"ClientID": &types.AttributeValueMemberS{Value: "client-1"},
+				"EventID":  &types.AttributeValueMemberS{Value: "0"},
+			},
+			Limit: aws.Int32(1),
+		}).Return(&dynamodb.QueryOutput{
+			Items: []map[string]types.AttributeValue{storedItem(t, record)},
+			LastEvaluatedKey: map[string]types.AttributeValue{
+				"ClientID": &types.AttributeValueMemberS{Value: "client-1"},
+				"EventID":  &types.AttributeValueMemberS{Value: "1"},
+			},
+		}, nil)
+
+		page, err := store.ListByClient(context.Background(), "client-1", ListOptions{
+			Types:     []string{"notification", "transaction"},
+			From:      time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			To:        time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC),
+			Limit:     1,
+			PageToken: encodePageToken("0"),
+		})
+
+		t.Run("should filter the query and continue from the page token", func(t *testing.T) {
+			assert.NoError(t, err)
+			client.AssertExpectations(t)
+		})
+
+		t.Run("should return a token for the next page", func(t *testing.T) {
+			assert.Equal(t, encodePageToken("1"), page.NextPageToken)
+		})
+	})
+
+	t.Run("when a query returns fewer matches than the limit", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		store := NewDynamoDBStore(client, "testTable", logger)
+		second := record
+		second.EventID = "3"
+		client.On("Query", mock.Anything, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+			return input.ExclusiveStartKey == nil
+		})).Return(&dynamodb.QueryOutput{
+			Items:            []map[string]types.AttributeValue{storedItem(t, record)},
+			LastEvaluatedKey: keyAttributes("client-1", "2"),
+		}, nil).Once()
+		client.On("Query", mock.Anything, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+			return assert.ObjectsAreEqual(keyAttributes("client-1", "2"), input.ExclusiveStartKey) && *input.Limit == 1
+		})).Return(&dynamodb.QueryOutput{
+			Items:            []map[string]types.AttributeValue{storedItem(t, second)},
+			LastEvaluatedKey: keyAttributes("client-1", "3"),
+		}, nil).Once()
+
+		page, err := store.ListByClient(context.Background(), "client-1", ListOptions{Types: []string{"notification"}, Limit: 2})
+
+		t.Run("should query again from where the previous query stopped", func(t *testing.T) {
+			assert.NoError(t, err)
+			client.AssertExpectations(t)
+		})
+
+		t.Run("then it should return Limit records and a token for the next page", func(t *testing.T) {
+			assert.Equal(t, []Record{record, second}, page.Records)
+			assert.Equal(t, encodePageToken("3"), page.NextPageToken)
+		})
+	})
+
+	t.Run("when the partition is exhausted before the limit", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		store := NewDynamoDBStore(client, "testTable", logger)
+		client.On("Query", mock.Anything, mock.Anything).Return(&dynamodb.QueryOutput{
+			Items: []map[string]types.AttributeValue{storedItem(t, record)},
+		}, nil).Once()
+
+		page, err := store.ListByClient(context.Background(), "client-1", ListOptions{Limit: 2})
+
+		t.Run("should return the last page without a token", func(t *testing.T) {
+			assert.NoError(t, err)
+			client.AssertExpectations(t)
+			assert.Equal(t, Page{Records: []Record{record}}, page)
+		})
+	})
+
+	t.Run("when the page token is invalid", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		store := NewDynamoDBStore(client, "testTable", logger)
+
+		_, err := store.ListByClient(context.Background(), "client-1", ListOptions{PageToken: "not a token!"})
+
+		t.Run("should return ErrInvalidPageToken without querying", func(t *testing.T) {
+			assert.ErrorIs(t, err, ErrInvalidPageToken)
+			client.AssertNotCalled(t, "Query", mock.Anything, mock.Anything)
+		})
+	})
+
+	t.Run("when Query returns an error", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		store := NewDynamoDBStore(client, "testTable", logger)
+		client.On("Query", mock.Anything, mock.Anything).Return(nil, assert.AnError)
+
+		_, err := store.ListByClient(context.Background(), "client-1", ListOptions{})
+
+		t.Run("should return the error", func(t *testing.T) {
+			assert.ErrorIs(t, err, assert.AnError)
+		})
+	})
+}
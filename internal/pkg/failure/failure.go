@@ -0,0 +1,39 @@
+// Package failure classifies processing errors as permanent or retryable.
+//
+// Errors are retryable unless explicitly marked permanent, so an unexpected
+// error is redelivered rather than lost.
+package failure
+
+import "errors"
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent marks err as a failure that will not succeed however many times it
+// is retried, e.g. an event that does not validate. Returns nil if err is nil.
+func Permanent(err error) error {
+	if err == nil || IsPermanent(err) {
+		return err
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether any error in err's chain was marked permanent.
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+// IsRetryable reports whether err is a transient failure worth retrying.
+func IsRetryable(err error) bool {
+	return err != nil && !IsPermanent(err)
+}
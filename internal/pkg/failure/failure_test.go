@@ -0,0 +1,51 @@
+package failure
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Permanent(t *testing.T) {
+	t.Run("when error is nil", func(t *testing.T) {
+		t.Run("should return nil", func(t *testing.T) {
+			assert.Nil(t, Permanent(nil))
+		})
+	})
+
+	t.Run("when error is marked permanent", func(t *testing.T) {
+		err := Permanent(assert.AnError)
+
+		t.Run("should keep the original message", func(t *testing.T) {
+			assert.Equal(t, assert.AnError.Error(), err.Error())
+		})
+
+		t.Run("should unwrap to the original error", func(t *testing.T) {
+			assert.ErrorIs(t, err, assert.AnError)
+		})
+
+		t.Run("should be permanent and not retryable", func(t *testing.T) {
+			assert.True(t, IsPermanent(err))
+			assert.False(t, IsRetryable(err))
+		})
+
+		t.Run("should stay permanent when wrapped", func(t *testing.T) {
+			assert.True(t, IsPermanent(fmt.Errorf("context: %w", err)))
+		})
+	})
+
+	t.Run("when error is not marked", func(t *testing.T) {
+		t.Run("should be retryable", func(t *testing.T) {
+			assert.False(t, IsPermanent(assert.AnError))
+			assert.True(t, IsRetryable(assert.AnError))
+		})
+	})
+
+	t.Run("when there is no error", func(t *testing.T) {
+		t.Run("should be neither permanent nor retryable", func(t *testing.T) {
+			assert.False(t, IsPermanent(nil))
+			assert.False(t, IsRetryable(nil))
+		})
+	})
+}
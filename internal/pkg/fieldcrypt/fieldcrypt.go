@@ -0,0 +1,262 @@
+// Package fieldcrypt encrypts selected fields of event data with envelope
+// encryption: each event's fields are encrypted with a data key of its own,
+// stored alongside them encrypted under a master key.
+package fieldcrypt
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/nivedita-verma/event-processor/pkg/eventspec"
+)
+
+// AllTypes is the Policy key for paths encrypted in events of every type.
+const AllTypes = "*"
+
+// Policy lists the paths encrypted in events of each type. Paths are dot
+// separated fields under data, e.g. "data.customer.email"; a "*" segment
+// matches every field of an object or element of an array.
+type Policy map[string][]string
+
+// Envelope records how an event's fields were encrypted: the data key,
+// encrypted under the master key KeyID, and the paths of the fields it
+// encrypted. Encrypted fields hold the base64 AES-GCM ciphertext of their JSON
+// value.
+type Envelope struct {
+	KeyID        string   `json:"keyId"`
+	EncryptedKey []byte   `json:"encryptedKey"`
+	Paths        []string `json:"paths"`
+}
+
+// Encryptor encrypts and decrypts the fields of a policy.
+type Encryptor struct {
+	provider KeyProvider
+	paths    map[string][]string
+}
+
+// New checks the policy's paths and returns an Encryptor using the provider's
+// keys.
+func New(provider KeyProvider, policy Policy) (*Encryptor, error) {
+	e := &Encryptor{provider: provider, paths: make(map[string][]string)}
+	for eventType, paths := range policy {
+		for _, path := range paths {
+			segments := strings.Split(path, ".")
+			if len(segments) < 2 || segments[0] != "data" || strings.Contains(path, "..") {
+				return nil, fmt.Errorf("invalid encryption path %q for type %s, expected data.<field>", path, eventType)
+			}
+			e.paths[eventType] = append(e.paths[eventType], path)
+		}
+	}
+	return e, nil
+}
+
+// Encrypt returns a copy of the event with the policy's fields encrypted and
+// the envelope to decrypt them with. Events without any of the fields are
+// returned unchanged with a nil envelope, and cost no data key.
+func (e *Encryptor) Encrypt(ctx context.Context, event eventspec.Event) (eventspec.Event, *Envelope, error) {
+	var located []location
+	for _, path := range append(e.paths[AllTypes], e.paths[event.Type]...) {
+		located = append(located, locate(event.Data, path)...)
+	}
+	if len(located) == 0 {
+		return event, nil, nil
+	}
+
+	key, err := e.provider.GenerateDataKey(ctx)
+	if err != nil {
+		return event, nil, err
+	}
+	aead, err := newAEAD(key.Plaintext)
+	if err != nil {
+		return event, nil, err
+	}
+
+	// Encrypt outer fields first, skipping fields nested in them
+	sort.SliceStable(located, func(i, j int) bool { return len(located[i].segments) < len(located[j].segments) })
+	data := copyValue(event.Data).(map[string]interface{})
+	envelope := &Envelope{KeyID: key.KeyID, EncryptedKey: key.Encrypted}
+	for _, loc := range located {
+		if encloses(envelope.Paths, loc.path) {
+			continue
+		}
+		plaintext, err := json.Marshal(loc.value)
+		if err != nil {
+			return event, nil, err
+		}
+		sealed, err := seal(aead, plaintext, additionalData(event, loc.path))
+		if err != nil {
+			return event, nil, err
+		}
+		set(data, loc.segments, base64.StdEncoding.EncodeToString(sealed))
+		envelope.Paths = append(envelope.Paths, loc.path)
+	}
+	sort.Strings(envelope.Paths)
+	event.Data = data
+	return event, envelope, nil
+}
+
+// Decrypt returns a copy of the event with the fields of the envelope
+// decrypted. Events without an envelope are returned unchanged.
+func (e *Encryptor) Decrypt(ctx context.Context, event eventspec.Event, envelope *Envelope) (eventspec.Event, error) {
+	if envelope == nil {
+		return event, nil
+	}
+	plaintextKey, err := e.provider.DecryptDataKey(ctx, envelope.KeyID, envelope.EncryptedKey)
+	if err != nil {
+		return event, err
+	}
+	aead, err := newAEAD(plaintextKey)
+	if err != nil {
+		return event, err
+	}
+
+	data, _ := copyValue(event.Data).(map[string]interface{})
+	for _, path := range envelope.Paths {
+		segments := strings.Split(path, ".")[1:]
+		encoded, ok := get(data, segments).(string)
+		if !ok {
+			return event, fmt.Errorf("encrypted field %s of event %s is missing", path, event.EventID)
+		}
+		sealed, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return event, fmt.Errorf("encrypted field %s of event %s: %w", path, event.EventID, err)
+		}
+		plaintext, err := open(aead, sealed, additionalData(event, path))
+		if err != nil {
+			return event, fmt.Errorf("failed to decrypt field %s of event %s: %w", path, event.EventID, err)
+		}
+		var value interface{}
+		if err := json.Unmarshal(plaintext, &value); err != nil {
+			return event, err
+		}
+		set(data, segments, value)
+	}
+	event.Data = data
+	return event, nil
+}
+
+// encloses reports whether path is one of paths or nested in one of them.
+func encloses(paths []string, path string) bool {
+	for _, p := range paths {
+		if p == path || strings.HasPrefix(path, p+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// additionalData binds a ciphertext to its event and field, so it cannot be
+// moved to another one.
+func additionalData(event eventspec.Event, path string) []byte {
+	return []byte(event.ClientID + "\x00" + event.EventID + "\x00" + path)
+}
+
+// location is a field found at a policy path, with wildcards resolved.
+type location struct {
+	path     string
+	segments []string
+	value    interface{}
+}
+
+// locate finds the fields at a policy path in data.
+func locate(data map[string]interface{}, path string) []location {
+	var found []location
+	var walk func(value interface{}, pattern, resolved []string)
+	walk = func(value interface{}, pattern, resolved []string) {
+		if len(pattern) == 0 {
+			segments := append([]string(nil), resolved...)
+			found = append(found, location{path: "data." + strings.Join(segments, "."), segments: segments, value: value})
+			return
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if pattern[0] == "*" {
+				keys := make([]string, 0, len(v))
+				for key := range v {
+					keys = append(keys, key)
+				}
+				sort.Strings(keys)
+				for _, key := range keys {
+					walk(v[key], pattern[1:], append(resolved, key))
+				}
+			} else if field, ok := v[pattern[0]]; ok {
+				walk(field, pattern[1:], append(resolved, pattern[0]))
+			}
+		case []interface{}:
+			if pattern[0] == "*" {
+				for i, element := range v {
+					walk(element, pattern[1:], append(resolved, fmt.Sprint(i)))
+				}
+			}
+		}
+	}
+	if data != nil {
+		walk(data, strings.Split(path, ".")[1:], nil)
+	}
+	return found
+}
+
+// get returns the value at the resolved segments, where array elements are
+// addressed by index.
+func get(value interface{}, segments []string) interface{} {
+	for _, segment := range segments {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			value = v[segment]
+		case []interface{}:
+			i, ok := index(segment, len(v))
+			if !ok {
+				return nil
+			}
+			value = v[i]
+		default:
+			return nil
+		}
+	}
+	return value
+}
+
+// set replaces the value at the resolved segments, which must exist.
+func set(value interface{}, segments []string, replacement interface{}) {
+	parent := get(value, segments[:len(segments)-1])
+	last := segments[len(segments)-1]
+	switch v := parent.(type) {
+	case map[string]interface{}:
+		v[last] = replacement
+	case []interface{}:
+		if i, ok := index(last, len(v)); ok {
+			v[i] = replacement
+		}
+	}
+}
+
+func index(segment string, length int) (int, bool) {
+	var i int
+	if _, err := fmt.Sscan(segment, &i); err != nil || i < 0 || i >= length {
+		return 0, false
+	}
+	return i, true
+}
+
+// copyValue deep copies the maps and arrays of decoded JSON.
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, field := range v {
+			copied[key] = copyValue(field)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, element := range v {
+			copied[i] = copyValue(element)
+		}
+		return copied
+	}
+	return value
+}
@@ -0,0 +1,117 @@
+package fieldcrypt
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nivedita-verma/event-processor/pkg/eventspec"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestEvent() eventspec.Event {
+	return eventspec.Event{
+		EventID:  "1",
+		ClientID: "client-1",
+		Type:     "transaction",
+		Data: map[string]interface{}{
+			"amount":     25.0,
+			"cardNumber": "4111111111111111",
+			"customer":   map[string]interface{}{"email": "jane@example.com", "tier": "gold"},
+			"items": []interface{}{
+				map[string]interface{}{"serial": "A-1"},
+				map[string]interface{}{"serial": "A-2"},
+			},
+		},
+	}
+}
+
+func Test_New(t *testing.T) {
+	t.Run("should reject paths outside data", func(t *testing.T) {
+		_, err := New(newLocalKeyProvider(t), Policy{AllTypes: {"clientId"}})
+		assert.ErrorContains(t, err, "invalid encryption path")
+	})
+}
+
+func Test_Encryptor(t *testing.T) {
+	encryptor, err := New(newLocalKeyProvider(t), Policy{
+		AllTypes:      {"data.cardNumber"},
+		"transaction": {"data.customer.email", "data.items.*.serial"},
+	})
+	assert.NoError(t, err)
+
+	t.Run("when the event has fields to encrypt", func(t *testing.T) {
+		event := newTestEvent()
+
+		encrypted, envelope, err := encryptor.Encrypt(context.Background(), event)
+		assert.NoError(t, err)
+
+		t.Run("should replace them with ciphertext", func(t *testing.T) {
+			assert.NotEqual(t, "4111111111111111", encrypted.Data["cardNumber"])
+			assert.IsType(t, "", encrypted.Data["cardNumber"])
+			assert.NotEqual(t, "jane@example.com", encrypted.Data["customer"].(map[string]interface{})["email"])
+			assert.NotEqual(t, "A-1", encrypted.Data["items"].([]interface{})[0].(map[string]interface{})["serial"])
+			assert.Equal(t, 25.0, encrypted.Data["amount"])
+			assert.Equal(t, "gold", encrypted.Data["customer"].(map[string]interface{})["tier"])
+		})
+
+		t.Run("should record the key and paths in the envelope", func(t *testing.T) {
+			assert.Equal(t, "local-key", envelope.KeyID)
+			assert.NotEmpty(t, envelope.EncryptedKey)
+			assert.Equal(t, []string{"data.cardNumber", "data.customer.email", "data.items.0.serial", "data.items.1.serial"}, envelope.Paths)
+		})
+
+		t.Run("should not modify the event", func(t *testing.T) {
+			assert.Equal(t, newTestEvent(), event)
+		})
+
+		t.Run("should decrypt to the original event", func(t *testing.T) {
+			decrypted, err := encryptor.Decrypt(context.Background(), encrypted, envelope)
+			assert.NoError(t, err)
+			assert.Equal(t, newTestEvent(), decrypted)
+		})
+
+		t.Run("should not decrypt fields moved to another event", func(t *testing.T) {
+			moved := encrypted
+			moved.EventID = "2"
+			_, err := encryptor.Decrypt(context.Background(), moved, envelope)
+			assert.ErrorContains(t, err, "failed to decrypt field data.cardNumber of event 2")
+		})
+	})
+
+	t.Run("when the event has none of the fields", func(t *testing.T) {
+		event := eventspec.Event{EventID: "1", Type: "notification", Data: map[string]interface{}{"message": "hello"}}
+
+		encrypted, envelope, err := encryptor.Encrypt(context.Background(), event)
+
+		t.Run("should return it unchanged without an envelope", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Nil(t, envelope)
+			assert.Equal(t, event, encrypted)
+		})
+
+		t.Run("should decrypt it unchanged", func(t *testing.T) {
+			decrypted, err := encryptor.Decrypt(context.Background(), event, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, event, decrypted)
+		})
+	})
+
+	t.Run("when a field to encrypt is nested in another", func(t *testing.T) {
+		nested, err := New(newLocalKeyProvider(t), Policy{"transaction": {"data.customer.email", "data.customer"}})
+		assert.NoError(t, err)
+
+		encrypted, envelope, err := nested.Encrypt(context.Background(), newTestEvent())
+		assert.NoError(t, err)
+
+		t.Run("should encrypt the outer field only", func(t *testing.T) {
+			assert.Equal(t, []string{"data.customer"}, envelope.Paths)
+			assert.IsType(t, "", encrypted.Data["customer"])
+		})
+
+		t.Run("should decrypt to the original event", func(t *testing.T) {
+			decrypted, err := nested.Decrypt(context.Background(), encrypted, envelope)
+			assert.NoError(t, err)
+			assert.Equal(t, newTestEvent(), decrypted)
+		})
+	})
+}
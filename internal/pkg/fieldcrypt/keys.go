@@ -0,0 +1,133 @@
+package fieldcrypt
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+)
+
+// dataKeySize is the size of AES-256 data keys.
+const dataKeySize = 32
+
+// DataKey is a key generated to encrypt one event, in plaintext and encrypted
+// under the provider's master key.
+type DataKey struct {
+	// KeyID identifies the master key the data key was encrypted under.
+	KeyID     string
+	Plaintext []byte
+	Encrypted []byte
+}
+
+// KeyProvider generates data keys and decrypts the data keys it generated.
+type KeyProvider interface {
+	GenerateDataKey(ctx context.Context) (DataKey, error)
+	DecryptDataKey(ctx context.Context, keyID string, encrypted []byte) ([]byte, error)
+}
+
+type kmsAPI interface {
+	GenerateDataKey(ctx context.Context, params *kms.GenerateDataKeyInput, optFns ...func(*kms.Options)) (*kms.GenerateDataKeyOutput, error)
+	Decrypt(ctx context.Context, params *kms.DecryptInput, optFns ...func(*kms.Options)) (*kms.DecryptOutput, error)
+}
+
+// KMSKeyProvider generates data keys under a KMS key.
+type KMSKeyProvider struct {
+	client kmsAPI
+	keyID  string
+}
+
+func NewKMSKeyProvider(api kmsAPI, keyID string) *KMSKeyProvider {
+	return &KMSKeyProvider{client: api, keyID: keyID}
+}
+
+func (p *KMSKeyProvider) GenerateDataKey(ctx context.Context) (DataKey, error) {
+	output, err := p.client.GenerateDataKey(ctx, &kms.GenerateDataKeyInput{
+		KeyId:   aws.String(p.keyID),
+		KeySpec: types.DataKeySpecAes256,
+	})
+	if err != nil {
+		return DataKey{}, fmt.Errorf("failed to generate data key: %w", err)
+	}
+	return DataKey{KeyID: aws.ToString(output.KeyId), Plaintext: output.Plaintext, Encrypted: output.CiphertextBlob}, nil
+}
+
+func (p *KMSKeyProvider) DecryptDataKey(ctx context.Context, keyID string, encrypted []byte) ([]byte, error) {
+	output, err := p.client.Decrypt(ctx, &kms.DecryptInput{
+		KeyId:          aws.String(keyID),
+		CiphertextBlob: encrypted,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data key: %w", err)
+	}
+	return output.Plaintext, nil
+}
+
+// LocalKeyProvider encrypts data keys under a master key held in memory. It is
+// intended for tests and local runs.
+type LocalKeyProvider struct {
+	keyID  string
+	master cipher.AEAD
+}
+
+// NewLocalKeyProvider returns a provider for a 32 byte master key.
+func NewLocalKeyProvider(keyID string, masterKey []byte) (*LocalKeyProvider, error) {
+	master, err := newAEAD(masterKey)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalKeyProvider{keyID: keyID, master: master}, nil
+}
+
+func (p *LocalKeyProvider) GenerateDataKey(context.Context) (DataKey, error) {
+	plaintext := make([]byte, dataKeySize)
+	if _, err := rand.Read(plaintext); err != nil {
+		return DataKey{}, err
+	}
+	encrypted, err := seal(p.master, plaintext, []byte(p.keyID))
+	if err != nil {
+		return DataKey{}, err
+	}
+	return DataKey{KeyID: p.keyID, Plaintext: plaintext, Encrypted: encrypted}, nil
+}
+
+func (p *LocalKeyProvider) DecryptDataKey(_ context.Context, keyID string, encrypted []byte) ([]byte, error) {
+	if keyID != p.keyID {
+		return nil, fmt.Errorf("unknown key %s", keyID)
+	}
+	return open(p.master, encrypted, []byte(keyID))
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != dataKeySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", dataKeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext with a random nonce, which it prefixes to the
+// ciphertext.
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(aead cipher.AEAD, sealed, additionalData []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
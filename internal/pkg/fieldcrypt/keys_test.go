@@ -0,0 +1,114 @@
+package fieldcrypt
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var testMasterKey = bytes.Repeat([]byte{7}, dataKeySize)
+
+func newLocalKeyProvider(t *testing.T) *LocalKeyProvider {
+	provider, err := NewLocalKeyProvider("local-key", testMasterKey)
+	assert.NoError(t, err)
+	return provider
+}
+
+func Test_LocalKeyProvider(t *testing.T) {
+	provider := newLocalKeyProvider(t)
+
+	t.Run("when a data key is generated", func(t *testing.T) {
+		key, err := provider.GenerateDataKey(context.Background())
+		assert.NoError(t, err)
+
+		t.Run("should return it encrypted and in plaintext", func(t *testing.T) {
+			assert.Equal(t, "local-key", key.KeyID)
+			assert.Len(t, key.Plaintext, dataKeySize)
+			assert.NotContains(t, string(key.Encrypted), string(key.Plaintext))
+		})
+
+		t.Run("should decrypt it", func(t *testing.T) {
+			plaintext, err := provider.DecryptDataKey(context.Background(), "local-key", key.Encrypted)
+			assert.NoError(t, err)
+			assert.Equal(t, key.Plaintext, plaintext)
+		})
+
+		t.Run("should not decrypt it for another key", func(t *testing.T) {
+			_, err := provider.DecryptDataKey(context.Background(), "other-key", key.Encrypted)
+			assert.ErrorContains(t, err, "unknown key other-key")
+		})
+	})
+
+	t.Run("when the master key is not 32 bytes", func(t *testing.T) {
+		_, err := NewLocalKeyProvider("local-key", []byte("short"))
+
+		t.Run("should return an error", func(t *testing.T) {
+			assert.ErrorContains(t, err, "key must be 32 bytes")
+		})
+	})
+}
+
+type mockKMSClient struct {
+	mock.Mock
+}
+
+func (m *mockKMSClient) GenerateDataKey(ctx context.Context, input *kms.GenerateDataKeyInput, opts ...func(*kms.Options)) (*kms.GenerateDataKeyOutput, error) {
+	args := m.Called(ctx, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*kms.GenerateDataKeyOutput), nil
+}
+
+func (m *mockKMSClient) Decrypt(ctx context.Context, input *kms.DecryptInput, opts ...func(*kms.Options)) (*kms.DecryptOutput, error) {
+	args := m.Called(ctx, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*kms.DecryptOutput), nil
+}
+
+func Test_KMSKeyProvider(t *testing.T) {
+	t.Run("when a data key is generated", func(t *testing.T) {
+		client := &mockKMSClient{}
+		client.On("GenerateDataKey", mock.Anything, &kms.GenerateDataKeyInput{KeyId: aws.String("alias/events"), KeySpec: types.DataKeySpecAes256}).
+			Return(&kms.GenerateDataKeyOutput{KeyId: aws.String("arn:key"), Plaintext: []byte("plain"), CiphertextBlob: []byte("blob")}, nil)
+
+		key, err := NewKMSKeyProvider(client, "alias/events").GenerateDataKey(context.Background())
+
+		t.Run("should return the key from KMS", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Equal(t, DataKey{KeyID: "arn:key", Plaintext: []byte("plain"), Encrypted: []byte("blob")}, key)
+		})
+	})
+
+	t.Run("when a data key is decrypted", func(t *testing.T) {
+		client := &mockKMSClient{}
+		client.On("Decrypt", mock.Anything, &kms.DecryptInput{KeyId: aws.String("arn:key"), CiphertextBlob: []byte("blob")}).
+			Return(&kms.DecryptOutput{Plaintext: []byte("plain")}, nil)
+
+		plaintext, err := NewKMSKeyProvider(client, "alias/events").DecryptDataKey(context.Background(), "arn:key", []byte("blob"))
+
+		t.Run("should return the plaintext from KMS", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Equal(t, []byte("plain"), plaintext)
+		})
+	})
+
+	t.Run("when KMS returns an error", func(t *testing.T) {
+		client := &mockKMSClient{}
+		client.On("GenerateDataKey", mock.Anything, mock.Anything).Return(nil, assert.AnError)
+
+		_, err := NewKMSKeyProvider(client, "alias/events").GenerateDataKey(context.Background())
+
+		t.Run("should return the error", func(t *testing.T) {
+			assert.ErrorIs(t, err, assert.AnError)
+		})
+	})
+}
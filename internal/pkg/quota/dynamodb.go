@@ -0,0 +1,145 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"go.uber.org/zap"
+)
+
+// bucketTTL is how long a bucket is kept after it was last full.
+const bucketTTL = time.Hour
+
+// The updates taking a token. A bucket in use is moved on by a token's
+// interval as long as it is not empty, i.e. FullAt is at most Burst-1
+// intervals away; a full or new bucket is set to be full an interval from now.
+const (
+	takeFromPartialUpdate    = "SET FullAt = FullAt + :interval, ExpiresAt = :expiresAt"
+	takeFromPartialCondition = "FullAt > :now AND FullAt <= :last"
+	takeFromFullUpdate       = "SET FullAt = :next, ExpiresAt = :expiresAt"
+	takeFromFullCondition    = "attribute_not_exists(FullAt) OR FullAt <= :now"
+)
+
+type dynamoDBAPI interface {
+	UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
+}
+
+// bucket is a token bucket as stored. Rather than its tokens, which would need
+// to be read to be refilled, a bucket holds the time it will be full again:
+// each token taken moves it on by the time a token takes to refill, and the
+// bucket is empty when it is Burst such intervals away. This is the generic
+// cell rate algorithm, which lets a token be taken by a single conditional
+// update.
+type bucket struct {
+	Key string
+	// FullAt is in epoch microseconds, as the refill depends on time elapsed
+	// at finer than second precision.
+	FullAt int64
+	// ExpiresAt is the table's TTL attribute, set to when the bucket would have
+	// been full for bucketTTL and can be recreated full without changing the
+	// outcome.
+	ExpiresAt time.Time `dynamodbav:",unixtime"`
+}
+
+// DynamoDBLimiter keeps buckets in a DynamoDB table keyed by Key, so a quota is
+// shared by every Lambda instance. Tokens are taken with atomic conditional
+// updates, so instances taking from the same bucket never overwrite each
+// other and need not retry. A limit that never refills takes no tokens.
+type DynamoDBLimiter struct {
+	client    dynamoDBAPI
+	tableName string
+	now       func() time.Time
+	logger    *zap.SugaredLogger
+}
+
+func NewDynamoDBLimiter(api dynamoDBAPI, tableName string, logger *zap.SugaredLogger) *DynamoDBLimiter {
+	return &DynamoDBLimiter{
+		client:    api,
+		tableName: tableName,
+		now:       time.Now,
+		logger:    logger,
+	}
+}
+
+func (l *DynamoDBLimiter) Take(ctx context.Context, key string, limit Limit) (bool, error) {
+	if limit.Burst < 1 || limit.Rate <= 0 {
+		return false, nil
+	}
+	now := l.now()
+
+	// Most tokens are taken from buckets in use, which are no longer full
+	taken, current, err := l.takeFromPartial(ctx, key, limit, now)
+	if err != nil || taken {
+		return taken, err
+	}
+	if current != nil && current.FullAt > now.UnixMicro() {
+		return false, nil
+	}
+	taken, _, err = l.update(ctx, key, takeFromFullUpdate, takeFromFullCondition, map[string]types.AttributeValue{
+		":now":       micros(now),
+		":next":      micros(now.Add(limit.interval())),
+		":expiresAt": expiresAt(now, limit),
+	})
+	if err != nil || taken {
+		return taken, err
+	}
+
+	// Another instance took from the bucket since it was found full, so it is
+	// in use now
+	l.logger.Debugf("Bucket %s was taken from while full, taking from it in use", key)
+	taken, _, err = l.takeFromPartial(ctx, key, limit, now)
+	return taken, err
+}
+
+func (l *DynamoDBLimiter) takeFromPartial(ctx context.Context, key string, limit Limit, now time.Time) (bool, *bucket, error) {
+	return l.update(ctx, key, takeFromPartialUpdate, takeFromPartialCondition, map[string]types.AttributeValue{
+		":now":       micros(now),
+		":last":      micros(now.Add(time.Duration(limit.Burst-1) * limit.interval())),
+		":interval":  &types.AttributeValueMemberN{Value: strconv.FormatInt(limit.interval().Microseconds(), 10)},
+		":expiresAt": expiresAt(now, limit),
+	})
+}
+
+// update applies an update to a bucket on condition, reporting whether the
+// condition held. If it did not, the bucket is returned as it was, or nil if
+// it did not exist.
+func (l *DynamoDBLimiter) update(ctx context.Context, key, update, condition string, values map[string]types.AttributeValue) (bool, *bucket, error) {
+	_, err := l.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                           aws.String(l.tableName),
+		Key:                                 map[string]types.AttributeValue{"Key": &types.AttributeValueMemberS{Value: key}},
+		UpdateExpression:                    aws.String(update),
+		ConditionExpression:                 aws.String(condition),
+		ExpressionAttributeValues:           values,
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	})
+	var conditionErr *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		if conditionErr.Item == nil {
+			return false, nil, nil
+		}
+		var current bucket
+		if err := attributevalue.UnmarshalMap(conditionErr.Item, &current); err != nil {
+			return false, nil, fmt.Errorf("failed to unmarshal bucket %s: %w", key, err)
+		}
+		return false, &current, nil
+	}
+	if err != nil {
+		return false, nil, err
+	}
+	return true, nil, nil
+}
+
+func micros(t time.Time) types.AttributeValue {
+	return &types.AttributeValueMemberN{Value: strconv.FormatInt(t.UnixMicro(), 10)}
+}
+
+func expiresAt(now time.Time, limit Limit) types.AttributeValue {
+	return &types.AttributeValueMemberN{Value: strconv.FormatInt(now.Add(limit.fillTime()+bucketTTL).Unix(), 10)}
+}
@@ -0,0 +1,211 @@
+package quota
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+type mockDynamoDBClient struct {
+	mock.Mock
+}
+
+func (m *mockDynamoDBClient) UpdateItem(ctx context.Context, input *dynamodb.UpdateItemInput, opts ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	args := m.Called(ctx, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dynamodb.UpdateItemOutput), nil
+}
+
+// fakeTable applies the limiter's updates atomically, as DynamoDB does, so
+// instances can be made to contend for a bucket.
+type fakeTable struct {
+	mu      sync.Mutex
+	buckets map[string]int64
+}
+
+func (f *fakeTable) UpdateItem(ctx context.Context, input *dynamodb.UpdateItemInput, opts ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := input.Key["Key"].(*types.AttributeValueMemberS).Value
+	value := func(name string) int64 {
+		n, _ := strconv.ParseInt(input.ExpressionAttributeValues[name].(*types.AttributeValueMemberN).Value, 10, 64)
+		return n
+	}
+	fullAt, ok := f.buckets[key]
+	switch *input.ConditionExpression {
+	case takeFromPartialCondition:
+		if ok && fullAt > value(":now") && fullAt <= value(":last") {
+			f.buckets[key] = fullAt + value(":interval")
+			return &dynamodb.UpdateItemOutput{}, nil
+		}
+	case takeFromFullCondition:
+		if !ok || fullAt <= value(":now") {
+			f.buckets[key] = value(":next")
+			return &dynamodb.UpdateItemOutput{}, nil
+		}
+	}
+	err := &types.ConditionalCheckFailedException{}
+	if ok {
+		err.Item, _ = attributevalue.MarshalMap(bucket{Key: key, FullAt: fullAt})
+	}
+	return nil, err
+}
+
+func Test_DynamoDBLimiter_Take(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	limit := Limit{Rate: 1, Burst: 5}
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	conditionFailed := func(b *bucket) error {
+		err := &types.ConditionalCheckFailedException{}
+		if b != nil {
+			err.Item, _ = attributevalue.MarshalMap(b)
+		}
+		return err
+	}
+	updated := func(client *mockDynamoDBClient, call int) *dynamodb.UpdateItemInput {
+		return client.Calls[call].Arguments.Get(1).(*dynamodb.UpdateItemInput)
+	}
+	micros := func(t time.Time) types.AttributeValue {
+		return &types.AttributeValueMemberN{Value: strconv.FormatInt(t.UnixMicro(), 10)}
+	}
+	newLimiter := func(client dynamoDBAPI) *DynamoDBLimiter {
+		limiter := NewDynamoDBLimiter(client, "quotaTable", logger)
+		limiter.now = func() time.Time { return now }
+		return limiter
+	}
+
+	t.Run("when the bucket is in use and has tokens", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		client.On("UpdateItem", mock.Anything, mock.Anything).Return(&dynamodb.UpdateItemOutput{}, nil)
+
+		taken, err := newLimiter(client).Take(context.Background(), "client-1", limit)
+
+		t.Run("should take a token in a single update on condition the bucket is not empty", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.True(t, taken)
+			client.AssertNumberOfCalls(t, "UpdateItem", 1)
+			input := updated(client, 0)
+			assert.Equal(t, takeFromPartialUpdate, *input.UpdateExpression)
+			assert.Equal(t, takeFromPartialCondition, *input.ConditionExpression)
+			assert.Equal(t, micros(now), input.ExpressionAttributeValues[":now"])
+			assert.Equal(t, micros(now.Add(4*time.Second)), input.ExpressionAttributeValues[":last"])
+			assert.Equal(t, &types.AttributeValueMemberN{Value: "1000000"}, input.ExpressionAttributeValues[":interval"])
+			assert.Equal(t, &types.AttributeValueMemberN{Value: strconv.FormatInt(now.Add(5*time.Second+bucketTTL).Unix(), 10)}, input.ExpressionAttributeValues[":expiresAt"])
+		})
+	})
+
+	t.Run("when the bucket does not exist", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		client.On("UpdateItem", mock.Anything, mock.Anything).Return(nil, conditionFailed(nil)).Once()
+		client.On("UpdateItem", mock.Anything, mock.Anything).Return(&dynamodb.UpdateItemOutput{}, nil).Once()
+
+		taken, err := newLimiter(client).Take(context.Background(), "client-1", limit)
+
+		t.Run("should create it full and take a token", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.True(t, taken)
+			input := updated(client, 1)
+			assert.Equal(t, takeFromFullUpdate, *input.UpdateExpression)
+			assert.Equal(t, takeFromFullCondition, *input.ConditionExpression)
+			assert.Equal(t, micros(now.Add(time.Second)), input.ExpressionAttributeValues[":next"])
+		})
+	})
+
+	t.Run("when the bucket has been full since before now", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		client.On("UpdateItem", mock.Anything, mock.Anything).Return(nil, conditionFailed(&bucket{Key: "client-1", FullAt: now.Add(-time.Minute).UnixMicro()})).Once()
+		client.On("UpdateItem", mock.Anything, mock.Anything).Return(&dynamodb.UpdateItemOutput{}, nil).Once()
+
+		taken, err := newLimiter(client).Take(context.Background(), "client-1", limit)
+
+		t.Run("should take a token from the full bucket", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.True(t, taken)
+			assert.Equal(t, takeFromFullUpdate, *updated(client, 1).UpdateExpression)
+		})
+	})
+
+	t.Run("when the bucket is empty", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		client.On("UpdateItem", mock.Anything, mock.Anything).Return(nil, conditionFailed(&bucket{Key: "client-1", FullAt: now.Add(5 * time.Second).UnixMicro()}))
+
+		taken, err := newLimiter(client).Take(context.Background(), "client-1", limit)
+
+		t.Run("should not take a token", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.False(t, taken)
+			client.AssertNumberOfCalls(t, "UpdateItem", 1)
+		})
+	})
+
+	t.Run("when another instance takes from the full bucket first", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		client.On("UpdateItem", mock.Anything, mock.Anything).Return(nil, conditionFailed(&bucket{Key: "client-1", FullAt: now.UnixMicro()})).Twice()
+		client.On("UpdateItem", mock.Anything, mock.Anything).Return(&dynamodb.UpdateItemOutput{}, nil).Once()
+
+		taken, err := newLimiter(client).Take(context.Background(), "client-1", limit)
+
+		t.Run("should take a token from the bucket in use", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.True(t, taken)
+			client.AssertNumberOfCalls(t, "UpdateItem", 3)
+			assert.Equal(t, takeFromPartialUpdate, *updated(client, 2).UpdateExpression)
+		})
+	})
+
+	t.Run("when instances contend for a bucket", func(t *testing.T) {
+		table := &fakeTable{buckets: make(map[string]int64)}
+		var taken atomic.Int64
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ok, err := newLimiter(table).Take(context.Background(), "client-1", limit)
+				assert.NoError(t, err)
+				if ok {
+					taken.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		t.Run("should take exactly the tokens in the bucket", func(t *testing.T) {
+			assert.Equal(t, int64(limit.Burst), taken.Load())
+		})
+
+		t.Run("then a token should be taken once it is refilled", func(t *testing.T) {
+			limiter := newLimiter(table)
+			limiter.now = func() time.Time { return now.Add(time.Second) }
+			ok, err := limiter.Take(context.Background(), "client-1", limit)
+			assert.NoError(t, err)
+			assert.True(t, ok)
+			ok, err = limiter.Take(context.Background(), "client-1", limit)
+			assert.NoError(t, err)
+			assert.False(t, ok)
+		})
+	})
+
+	t.Run("when UpdateItem returns an error", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		client.On("UpdateItem", mock.Anything, mock.Anything).Return(nil, assert.AnError)
+
+		_, err := newLimiter(client).Take(context.Background(), "client-1", limit)
+
+		t.Run("should return the error", func(t *testing.T) {
+			assert.ErrorIs(t, err, assert.AnError)
+		})
+	})
+}
@@ -0,0 +1,43 @@
+package quota
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryBucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+// MemoryLimiter keeps buckets in memory. It is intended for tests and local
+// runs, as buckets are not shared between instances.
+type MemoryLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]memoryBucket
+	now     func() time.Time
+}
+
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{
+		buckets: make(map[string]memoryBucket),
+		now:     time.Now,
+	}
+}
+
+func (l *MemoryLimiter) Take(_ context.Context, key string, limit Limit) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	current, ok := l.buckets[key]
+	if !ok {
+		current = memoryBucket{tokens: float64(limit.Burst), updatedAt: now}
+	}
+	tokens := limit.refill(current.tokens, now.Sub(current.updatedAt))
+	if tokens < 1 {
+		return false, nil
+	}
+	l.buckets[key] = memoryBucket{tokens: tokens - 1, updatedAt: now}
+	return true, nil
+}
@@ -0,0 +1,42 @@
+package quota
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MemoryLimiter_Take(t *testing.T) {
+	limit := Limit{Rate: 1, Burst: 2}
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	limiter := NewMemoryLimiter()
+	limiter.now = func() time.Time { return start }
+	take := func(key string) bool {
+		taken, err := limiter.Take(context.Background(), key, limit)
+		assert.NoError(t, err)
+		return taken
+	}
+
+	t.Run("when the bucket is new", func(t *testing.T) {
+		t.Run("should allow up to the burst", func(t *testing.T) {
+			assert.True(t, take("client-1"))
+			assert.True(t, take("client-1"))
+			assert.False(t, take("client-1"))
+		})
+
+		t.Run("should keep buckets apart", func(t *testing.T) {
+			assert.True(t, take("client-2"))
+		})
+	})
+
+	t.Run("when time has passed", func(t *testing.T) {
+		limiter.now = func() time.Time { return start.Add(1500 * time.Millisecond) }
+
+		t.Run("should allow the tokens refilled since", func(t *testing.T) {
+			assert.True(t, take("client-1"))
+			assert.False(t, take("client-1"))
+		})
+	})
+}
@@ -0,0 +1,52 @@
+// Package quota limits how many events a client may send with token buckets.
+//
+// Each bucket holds up to Burst tokens and is refilled at Rate tokens per
+// second. Every event takes a token; an event arriving at an empty bucket is
+// over quota.
+package quota
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+)
+
+// ErrExceeded is returned for events over their client's quota.
+var ErrExceeded = errors.New("quota exceeded")
+
+// Limit is the size and refill rate of a bucket.
+type Limit struct {
+	// Rate is how many tokens are added to the bucket per second.
+	Rate float64
+	// Burst is how many tokens the bucket holds, i.e. how many events may
+	// arrive at once after a quiet period.
+	Burst int
+}
+
+// refill returns the tokens in a bucket that held tokens elapsed ago.
+func (l Limit) refill(tokens float64, elapsed time.Duration) float64 {
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	return math.Min(float64(l.Burst), tokens+elapsed.Seconds()*l.Rate)
+}
+
+// fillTime is how long an empty bucket takes to fill.
+func (l Limit) fillTime() time.Duration {
+	if l.Rate <= 0 {
+		return 0
+	}
+	return time.Duration(float64(l.Burst) / l.Rate * float64(time.Second))
+}
+
+// interval is how long a token takes to refill.
+func (l Limit) interval() time.Duration {
+	return time.Duration(math.Ceil(float64(time.Second) / l.Rate))
+}
+
+// Api takes tokens from buckets, reporting whether a token was available.
+// Buckets are created full on first use.
+type Api interface {
+	Take(ctx context.Context, key string, limit Limit) (bool, error)
+}
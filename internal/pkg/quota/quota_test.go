@@ -0,0 +1,34 @@
+package quota
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Limit_refill(t *testing.T) {
+	limit := Limit{Rate: 2, Burst: 10}
+
+	t.Run("should add tokens at the rate", func(t *testing.T) {
+		assert.Equal(t, 4.0, limit.refill(1, 1500*time.Millisecond))
+	})
+
+	t.Run("should not exceed the burst", func(t *testing.T) {
+		assert.Equal(t, 10.0, limit.refill(9, time.Minute))
+	})
+
+	t.Run("should not remove tokens when the clock goes back", func(t *testing.T) {
+		assert.Equal(t, 3.0, limit.refill(3, -time.Second))
+	})
+}
+
+func Test_Limit_fillTime(t *testing.T) {
+	t.Run("should be the time to refill the burst", func(t *testing.T) {
+		assert.Equal(t, 5*time.Second, Limit{Rate: 2, Burst: 10}.fillTime())
+	})
+
+	t.Run("should be zero without a rate", func(t *testing.T) {
+		assert.Zero(t, Limit{Burst: 10}.fillTime())
+	})
+}
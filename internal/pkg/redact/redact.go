@@ -0,0 +1,186 @@
+// Package redact masks sensitive event data before it is logged.
+package redact
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/nivedita-verma/event-processor/pkg/eventspec"
+)
+
+// Mask replaces redacted values.
+const Mask = "[REDACTED]"
+
+// AllTypes is the Policy key for paths redacted from events of every type.
+const AllTypes = "*"
+
+// Policy lists the paths redacted from events of each type. Paths are dot
+// separated fields under data, e.g. "data.customer.email"; a "*" segment
+// matches every field of an object or element of an array. Redacting a field
+// redacts everything nested in it.
+type Policy map[string][]string
+
+// DefaultPolicy redacts card numbers, email addresses and IP addresses.
+func DefaultPolicy() Policy {
+	return Policy{
+		AllTypes:       {"data.cardNumber", "data.email", "data.ipAddress"},
+		"transaction":  {"data.card", "data.customer.email", "data.customer.ipAddress"},
+		"notification": {"data.recipient"},
+	}
+}
+
+// Redactor masks the paths of a policy in events, message bodies and
+// validation errors. A nil Redactor redacts nothing.
+type Redactor struct {
+	paths map[string][][]string
+	// all holds the paths of every type, for errors of events whose type is
+	// not known.
+	all [][]string
+}
+
+// New checks the policy's paths and returns a Redactor for it.
+func New(policy Policy) (*Redactor, error) {
+	r := &Redactor{paths: make(map[string][][]string)}
+	for eventType, paths := range policy {
+		for _, path := range paths {
+			segments := strings.Split(path, ".")
+			if len(segments) < 2 || segments[0] != "data" || strings.Contains(path, "..") {
+				return nil, fmt.Errorf("invalid redaction path %q for type %s, expected data.<field>", path, eventType)
+			}
+			r.paths[eventType] = append(r.paths[eventType], segments[1:])
+			r.all = append(r.all, segments[1:])
+		}
+	}
+	return r, nil
+}
+
+// MustNew is like New but panics if the policy is invalid. It is intended for
+// policies defined in code, such as DefaultPolicy.
+func MustNew(policy Policy) *Redactor {
+	r, err := New(policy)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Event returns a copy of the event with its sensitive data masked. The event
+// itself is not modified.
+func (r *Redactor) Event(event eventspec.Event) eventspec.Event {
+	if r == nil {
+		return event
+	}
+	if data, ok := r.data(event.Type, event.Data).(map[string]interface{}); ok {
+		event.Data = data
+	}
+	return event
+}
+
+// Body returns the message body with the sensitive data of the event it holds
+// masked. A body that is not a JSON object cannot be redacted selectively and
+// is replaced entirely.
+func (r *Redactor) Body(body string) string {
+	if r == nil || body == "" {
+		return body
+	}
+	var document map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &document); err != nil {
+		return fmt.Sprintf("%s (body of %d bytes that is not a JSON object)", Mask, len(body))
+	}
+	eventType, _ := document["type"].(string)
+	if data, ok := document["data"]; ok {
+		document["data"] = r.data(eventType, data)
+	}
+	redacted, err := json.Marshal(document)
+	if err != nil {
+		return Mask
+	}
+	return string(redacted)
+}
+
+// ValidationErrors masks the messages of errors about sensitive fields, or
+// fields containing them, which may quote the offending value. The event's
+// type may not be known, so fields redacted for any type are masked.
+func (r *Redactor) ValidationErrors(errs eventspec.ValidationErrors) eventspec.ValidationErrors {
+	if r == nil || len(errs) == 0 {
+		return errs
+	}
+	redacted := make(eventspec.ValidationErrors, len(errs))
+	for i, err := range errs {
+		redacted[i] = err
+		tokens := strings.Split(err.Pointer, "/")
+		// A pointer to a data field is /data/<field>...
+		if len(tokens) < 3 || tokens[1] != "data" {
+			continue
+		}
+		for _, path := range r.all {
+			if overlaps(path, tokens[2:]) {
+				redacted[i].Message = Mask
+				break
+			}
+		}
+	}
+	return redacted
+}
+
+// data masks the paths of the type and of all types in data, copying only
+// what it changes.
+func (r *Redactor) data(eventType string, data interface{}) interface{} {
+	for _, path := range r.paths[AllTypes] {
+		data = mask(data, path)
+	}
+	if eventType != AllTypes {
+		for _, path := range r.paths[eventType] {
+			data = mask(data, path)
+		}
+	}
+	return data
+}
+
+// mask returns value with the value at path replaced by Mask.
+func mask(value interface{}, path []string) interface{} {
+	if len(path) == 0 {
+		return Mask
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		var copied map[string]interface{}
+		for key, field := range v {
+			if path[0] != "*" && path[0] != key {
+				continue
+			}
+			if copied == nil {
+				copied = make(map[string]interface{}, len(v))
+				for k, f := range v {
+					copied[k] = f
+				}
+			}
+			copied[key] = mask(field, path[1:])
+		}
+		if copied == nil {
+			return v
+		}
+		return copied
+	case []interface{}:
+		if path[0] != "*" {
+			return v
+		}
+		copied := make([]interface{}, len(v))
+		for i, element := range v {
+			copied[i] = mask(element, path[1:])
+		}
+		return copied
+	}
+	return value
+}
+
+// overlaps reports whether the pointer tokens are at, under or above the path.
+func overlaps(path, tokens []string) bool {
+	for i := 0; i < min(len(path), len(tokens)); i++ {
+		if path[i] != "*" && path[i] != tokens[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,139 @@
+package redact
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/nivedita-verma/event-processor/pkg/eventspec"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRedactor(t *testing.T) *Redactor {
+	r, err := New(Policy{
+		AllTypes:      {"data.email"},
+		"transaction": {"data.cardNumber", "data.customer.ipAddress", "data.items.*.serial"},
+	})
+	assert.NoError(t, err)
+	return r
+}
+
+func Test_New(t *testing.T) {
+	for _, path := range []string{"cardNumber", "data", "eventId", "data..cardNumber"} {
+		t.Run("should reject the path "+path, func(t *testing.T) {
+			_, err := New(Policy{AllTypes: {path}})
+			assert.ErrorContains(t, err, "invalid redaction path")
+		})
+	}
+
+	t.Run("should accept the default policy", func(t *testing.T) {
+		_, err := New(DefaultPolicy())
+		assert.NoError(t, err)
+	})
+}
+
+func Test_Redactor_Event(t *testing.T) {
+	r := newRedactor(t)
+	event := eventspec.Event{
+		EventID:  "1",
+		ClientID: "client-1",
+		Type:     "transaction",
+		Data: map[string]interface{}{
+			"amount":     10.0,
+			"cardNumber": "4111111111111111",
+			"email":      "jane@example.com",
+			"customer":   map[string]interface{}{"ipAddress": "192.0.2.1", "tier": "gold"},
+			"items": []interface{}{
+				map[string]interface{}{"serial": "A-1", "sku": "sku-1"},
+				map[string]interface{}{"serial": "A-2", "sku": "sku-2"},
+			},
+		},
+	}
+
+	redacted := r.Event(event)
+
+	t.Run("should mask the paths of the type and of all types", func(t *testing.T) {
+		assert.Equal(t, map[string]interface{}{
+			"amount":     10.0,
+			"cardNumber": Mask,
+			"email":      Mask,
+			"customer":   map[string]interface{}{"ipAddress": Mask, "tier": "gold"},
+			"items": []interface{}{
+				map[string]interface{}{"serial": Mask, "sku": "sku-1"},
+				map[string]interface{}{"serial": Mask, "sku": "sku-2"},
+			},
+		}, redacted.Data)
+		assert.Equal(t, "1", redacted.EventID)
+	})
+
+	t.Run("should not modify the event", func(t *testing.T) {
+		assert.Equal(t, "4111111111111111", event.Data["cardNumber"])
+		assert.Equal(t, "192.0.2.1", event.Data["customer"].(map[string]interface{})["ipAddress"])
+		assert.Equal(t, "A-1", event.Data["items"].([]interface{})[0].(map[string]interface{})["serial"])
+	})
+
+	t.Run("should only mask the paths of all types for other types", func(t *testing.T) {
+		other := r.Event(eventspec.Event{Type: "notification", Data: map[string]interface{}{"cardNumber": "4111111111111111", "email": "jane@example.com"}})
+		assert.Equal(t, map[string]interface{}{"cardNumber": "4111111111111111", "email": Mask}, other.Data)
+	})
+
+	t.Run("should leave events without the fields unchanged", func(t *testing.T) {
+		other := r.Event(eventspec.Event{Type: "transaction", Data: map[string]interface{}{"customer": "client-1"}})
+		assert.Equal(t, map[string]interface{}{"customer": "client-1"}, other.Data)
+	})
+
+	t.Run("should redact nothing when nil", func(t *testing.T) {
+		var none *Redactor
+		assert.Equal(t, event, none.Event(event))
+	})
+}
+
+func Test_Redactor_Body(t *testing.T) {
+	r := newRedactor(t)
+
+	t.Run("when the body is an event", func(t *testing.T) {
+		body := r.Body(`{"eventId":"1","type":"transaction","data":{"cardNumber":"4111111111111111","amount":10}}`)
+
+		t.Run("should mask its sensitive data", func(t *testing.T) {
+			var document map[string]interface{}
+			assert.NoError(t, json.Unmarshal([]byte(body), &document))
+			assert.Equal(t, map[string]interface{}{"cardNumber": Mask, "amount": 10.0}, document["data"])
+			assert.Equal(t, "1", document["eventId"])
+		})
+	})
+
+	t.Run("when the body is not a JSON object", func(t *testing.T) {
+		body := r.Body(`{"cardNumber":"4111111111111111"`)
+
+		t.Run("should replace it entirely", func(t *testing.T) {
+			assert.NotContains(t, body, "4111111111111111")
+			assert.Contains(t, body, Mask)
+		})
+	})
+}
+
+func Test_Redactor_ValidationErrors(t *testing.T) {
+	r := newRedactor(t)
+	errs := eventspec.ValidationErrors{
+		{Code: eventspec.CodeInvalidValue, Pointer: "/data/cardNumber", Message: "'4111' is too short"},
+		{Code: eventspec.CodeInvalidValue, Pointer: "/data/customer", Message: "got string '192.0.2.1', want object"},
+		{Code: eventspec.CodeInvalidValue, Pointer: "/data/items/0/serial", Message: "'A-1' is invalid"},
+		{Code: eventspec.CodeInvalidValue, Pointer: "/data/currency", Message: "'gbp' does not match pattern"},
+		{Code: eventspec.CodeMissingField, Pointer: "/clientId", Message: "must not be empty"},
+	}
+
+	redacted := r.ValidationErrors(errs)
+
+	t.Run("should mask the messages of errors at, under or above sensitive fields", func(t *testing.T) {
+		assert.Equal(t, eventspec.ValidationErrors{
+			{Code: eventspec.CodeInvalidValue, Pointer: "/data/cardNumber", Message: Mask},
+			{Code: eventspec.CodeInvalidValue, Pointer: "/data/customer", Message: Mask},
+			{Code: eventspec.CodeInvalidValue, Pointer: "/data/items/0/serial", Message: Mask},
+			{Code: eventspec.CodeInvalidValue, Pointer: "/data/currency", Message: "'gbp' does not match pattern"},
+			{Code: eventspec.CodeMissingField, Pointer: "/clientId", Message: "must not be empty"},
+		}, redacted)
+	})
+
+	t.Run("should not modify the errors", func(t *testing.T) {
+		assert.Equal(t, "'4111' is too short", errs[0].Message)
+	})
+}
@@ -0,0 +1,373 @@
+package rules
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Expression is a compiled rule condition.
+//
+// Conditions compare fields of an event with literals, e.g.
+//
+//	type == "transaction" && data.amount > 10000
+//
+// Fields are eventId, clientId, type and data, with nested data fields reached
+// with dots. Literals are double quoted strings, numbers, true, false and null.
+// Operators are ==, !=, <, <=, >, >=, &&, || and !, grouped with parentheses.
+// A missing field is null. Ordering operators compare numbers with numbers and
+// strings with strings, and are false for anything else, so a condition never
+// fails at evaluation time.
+type Expression struct {
+	source string
+	root   node
+}
+
+// Compile parses a condition.
+func Compile(source string) (*Expression, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid condition %q: %w", source, err)
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err == nil && p.peek().kind != tokenEOF {
+		err = fmt.Errorf("unexpected %s", p.peek())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid condition %q: %w", source, err)
+	}
+	return &Expression{source: source, root: root}, nil
+}
+
+// Match reports whether the condition holds for the given fields.
+func (e *Expression) Match(fields map[string]interface{}) bool {
+	return e.root.eval(fields) == true
+}
+
+func (e *Expression) String() string {
+	return e.source
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenOperator
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	value interface{}
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of condition"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+var operators = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "(", ")"}
+
+func tokenize(source string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(source); {
+		c := rune(source[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '"':
+			end := i + 1
+			for end < len(source) && source[end] != '"' {
+				if source[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(source) {
+				return nil, fmt.Errorf("unterminated string at offset %d", i)
+			}
+			text := source[i : end+1]
+			value, err := strconv.Unquote(text)
+			if err != nil {
+				return nil, fmt.Errorf("invalid string %s", text)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: text, value: value})
+			i = end + 1
+		case unicode.IsDigit(c) || (c == '-' && i+1 < len(source) && unicode.IsDigit(rune(source[i+1]))):
+			end := i + 1
+			for end < len(source) && (unicode.IsDigit(rune(source[end])) || source[end] == '.') {
+				end++
+			}
+			text := source[i:end]
+			value, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %s", text)
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: text, value: value})
+			i = end
+		case unicode.IsLetter(c) || c == '_':
+			end := i + 1
+			for end < len(source) && (unicode.IsLetter(rune(source[end])) || unicode.IsDigit(rune(source[end])) || source[end] == '_' || source[end] == '.') {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: source[i:end]})
+			i = end
+		default:
+			operator := ""
+			for _, candidate := range operators {
+				if strings.HasPrefix(source[i:], candidate) {
+					operator = candidate
+					break
+				}
+			}
+			if operator == "" {
+				return nil, fmt.Errorf("unexpected character %q at offset %d", c, i)
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: operator})
+			i += len(operator)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF}), nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isOperator(operators ...string) bool {
+	t := p.peek()
+	if t.kind != tokenOperator {
+		return false
+	}
+	for _, operator := range operators {
+		if t.text == operator {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	for err == nil && p.isOperator("||") {
+		p.next()
+		var right node
+		if right, err = p.parseAnd(); err == nil {
+			left = orNode{left, right}
+		}
+	}
+	return left, err
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseComparison()
+	for err == nil && p.isOperator("&&") {
+		p.next()
+		var right node
+		if right, err = p.parseComparison(); err == nil {
+			left = andNode{left, right}
+		}
+	}
+	return left, err
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil || !p.isOperator("==", "!=", "<", "<=", ">", ">=") {
+		return left, err
+	}
+	operator := p.next().text
+	right, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	return compareNode{operator, left, right}, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.isOperator("!") {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString, tokenNumber:
+		return literalNode{t.value}, nil
+	case tokenIdent:
+		switch t.text {
+		case "true":
+			return literalNode{true}, nil
+		case "false":
+			return literalNode{false}, nil
+		case "null":
+			return literalNode{nil}, nil
+		}
+		return fieldNode{strings.Split(t.text, ".")}, nil
+	case tokenOperator:
+		if t.text == "(" {
+			inner, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if !p.isOperator(")") {
+				return nil, fmt.Errorf("expected \")\" but found %s", p.peek())
+			}
+			p.next()
+			return inner, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected %s", t)
+}
+
+type node interface {
+	eval(fields map[string]interface{}) interface{}
+}
+
+type literalNode struct {
+	value interface{}
+}
+
+func (n literalNode) eval(map[string]interface{}) interface{} {
+	return n.value
+}
+
+type fieldNode struct {
+	path []string
+}
+
+func (n fieldNode) eval(fields map[string]interface{}) interface{} {
+	var value interface{} = fields
+	for _, name := range n.path {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[name]
+	}
+	return normalise(value)
+}
+
+type notNode struct {
+	operand node
+}
+
+func (n notNode) eval(fields map[string]interface{}) interface{} {
+	return n.operand.eval(fields) != true
+}
+
+type andNode struct {
+	left, right node
+}
+
+func (n andNode) eval(fields map[string]interface{}) interface{} {
+	return n.left.eval(fields) == true && n.right.eval(fields) == true
+}
+
+type orNode struct {
+	left, right node
+}
+
+func (n orNode) eval(fields map[string]interface{}) interface{} {
+	return n.left.eval(fields) == true || n.right.eval(fields) == true
+}
+
+type compareNode struct {
+	operator    string
+	left, right node
+}
+
+func (n compareNode) eval(fields map[string]interface{}) interface{} {
+	left, right := n.left.eval(fields), n.right.eval(fields)
+	switch n.operator {
+	case "==":
+		return equal(left, right)
+	case "!=":
+		return !equal(left, right)
+	}
+
+	var order int
+	switch l := left.(type) {
+	case float64:
+		r, ok := right.(float64)
+		if !ok {
+			return false
+		}
+		order = compareNumbers(l, r)
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			return false
+		}
+		order = strings.Compare(l, r)
+	default:
+		return false
+	}
+	switch n.operator {
+	case "<":
+		return order < 0
+	case "<=":
+		return order <= 0
+	case ">":
+		return order > 0
+	default:
+		return order >= 0
+	}
+}
+
+func compareNumbers(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func equal(a, b interface{}) bool {
+	return reflect.DeepEqual(a, b)
+}
+
+// normalise converts numbers to float64, as literals are, so that data
+// decoded from sources other than JSON compares as expected.
+func normalise(value interface{}) interface{} {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	}
+	return value
+}
@@ -0,0 +1,73 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Expression_Match(t *testing.T) {
+	fields := map[string]interface{}{
+		"type":     "transaction",
+		"clientId": "client-1",
+		"data": map[string]interface{}{
+			"amount":   12000.0,
+			"count":    3,
+			"currency": "GBP",
+			"flagged":  true,
+			"customer": map[string]interface{}{"tier": "gold"},
+		},
+	}
+	cases := []struct {
+		condition string
+		expected  bool
+	}{
+		{`type == "transaction"`, true},
+		{`type != "transaction"`, false},
+		{`type == "transaction" && data.amount > 10000`, true},
+		{`type == "transaction" && data.amount > 20000`, false},
+		{`data.amount >= 12000 && data.amount <= 12000`, true},
+		{`data.amount < -1`, false},
+		{`data.count == 3`, true},
+		{`data.currency < "USD"`, true},
+		{`data.flagged`, true},
+		{`!data.flagged`, false},
+		{`data.customer.tier == "gold"`, true},
+		{`data.missing == null`, true},
+		{`data.missing.nested == null`, true},
+		{`data.missing > 1`, false},
+		{`data.currency > 1`, false},
+		{`type == "notification" || data.amount > 10000`, true},
+		{`!(type == "notification" || clientId == "client-2")`, true},
+		{`type == "notification" || type == "transaction" && data.amount > 20000`, false},
+		{`"a \"quoted\" string" == "a \"quoted\" string"`, true},
+	}
+
+	for _, c := range cases {
+		t.Run("should evaluate "+c.condition, func(t *testing.T) {
+			expression, err := Compile(c.condition)
+			if assert.NoError(t, err) {
+				assert.Equal(t, c.expected, expression.Match(fields))
+			}
+		})
+	}
+}
+
+func Test_Compile(t *testing.T) {
+	invalid := []string{
+		``,
+		`type ==`,
+		`type == "transaction`,
+		`(type == "transaction"`,
+		`type == "transaction")`,
+		`type = "transaction"`,
+		`data.amount > 1 2`,
+	}
+
+	for _, condition := range invalid {
+		t.Run("should reject "+condition, func(t *testing.T) {
+			_, err := Compile(condition)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"gopkg.in/yaml.v3"
+)
+
+// File is the layout of a rules file.
+type File struct {
+	Rules []Rule `json:"rules" yaml:"rules"`
+}
+
+// LoadFile reads rules from a JSON or YAML file, chosen by its extension.
+func LoadFile(path string) ([]Rule, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file := File{}
+	switch filepath.Ext(path) {
+	case ".json":
+		err = json.Unmarshal(raw, &file)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(raw, &file)
+	default:
+		return nil, fmt.Errorf("unsupported rules file %s, expected .json, .yaml or .yml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse rules file %s: %w", path, err)
+	}
+	return file.Rules, nil
+}
+
+type dynamoDBAPI interface {
+	Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+}
+
+// LoadDynamoDB reads every rule from a table, sorted by their Order and then
+// by Name.
+func LoadDynamoDB(ctx context.Context, api dynamoDBAPI, tableName string) ([]Rule, error) {
+	var rules []Rule
+	paginator := dynamodb.NewScanPaginator(api, &dynamodb.ScanInput{TableName: aws.String(tableName)})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read rules from %s: %w", tableName, err)
+		}
+		var pageRules []Rule
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &pageRules); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal rules from %s: %w", tableName, err)
+		}
+		rules = append(rules, pageRules...)
+	}
+	sort.SliceStable(rules, func(i, j int) bool {
+		if rules[i].Order != rules[j].Order {
+			return rules[i].Order < rules[j].Order
+		}
+		return rules[i].Name < rules[j].Name
+	})
+	return rules, nil
+}
@@ -0,0 +1,134 @@
+package rules
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func Test_LoadFile(t *testing.T) {
+	expected := []Rule{
+		{Name: "large", When: `data.amount > 10000`, Action: ActionSetPriority, Value: "high"},
+		{Name: "tests", When: `clientId == "test"`, Action: ActionDrop},
+	}
+
+	t.Run("when the file is YAML", func(t *testing.T) {
+		rules, err := LoadFile(writeFile(t, "rules.yaml", `
+rules:
+  - name: large
+    when: data.amount > 10000
+    action: set-priority
+    value: high
+  - name: tests
+    when: clientId == "test"
+    action: drop
+`))
+
+		t.Run("should return the rules in order", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Equal(t, expected, rules)
+		})
+	})
+
+	t.Run("when the file is JSON", func(t *testing.T) {
+		rules, err := LoadFile(writeFile(t, "rules.json", `{"rules": [
+			{"name": "large", "when": "data.amount > 10000", "action": "set-priority", "value": "high"},
+			{"name": "tests", "when": "clientId == \"test\"", "action": "drop"}
+		]}`))
+
+		t.Run("should return the rules in order", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Equal(t, expected, rules)
+		})
+	})
+
+	t.Run("when the file is of another format", func(t *testing.T) {
+		_, err := LoadFile(writeFile(t, "rules.txt", ``))
+
+		t.Run("should return an error", func(t *testing.T) {
+			assert.ErrorContains(t, err, "unsupported rules file")
+		})
+	})
+
+	t.Run("when the file cannot be parsed", func(t *testing.T) {
+		_, err := LoadFile(writeFile(t, "rules.json", `{"rules": [`))
+
+		t.Run("should return an error", func(t *testing.T) {
+			assert.ErrorContains(t, err, "failed to parse rules file")
+		})
+	})
+}
+
+type mockDynamoDBClient struct {
+	mock.Mock
+}
+
+func (m *mockDynamoDBClient) Scan(ctx context.Context, input *dynamodb.ScanInput, opts ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	args := m.Called(ctx, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dynamodb.ScanOutput), nil
+}
+
+func Test_LoadDynamoDB(t *testing.T) {
+	item := func(rule Rule) map[string]types.AttributeValue {
+		item, err := attributevalue.MarshalMap(rule)
+		assert.NoError(t, err)
+		return item
+	}
+
+	t.Run("when the table has several pages of rules", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		lastKey := map[string]types.AttributeValue{"Name": &types.AttributeValueMemberS{Value: "b"}}
+		client.On("Scan", mock.Anything, mock.MatchedBy(func(input *dynamodb.ScanInput) bool {
+			return input.ExclusiveStartKey == nil
+		})).Return(&dynamodb.ScanOutput{
+			Items:            []map[string]types.AttributeValue{item(Rule{Name: "b", When: "true", Action: ActionDrop, Order: 2})},
+			LastEvaluatedKey: lastKey,
+		}, nil)
+		client.On("Scan", mock.Anything, mock.MatchedBy(func(input *dynamodb.ScanInput) bool {
+			return input.ExclusiveStartKey != nil
+		})).Return(&dynamodb.ScanOutput{
+			Items: []map[string]types.AttributeValue{
+				item(Rule{Name: "c", When: "true", Action: ActionDrop, Order: 1}),
+				item(Rule{Name: "a", When: "true", Action: ActionDrop, Order: 2}),
+			},
+		}, nil)
+
+		rules, err := LoadDynamoDB(context.Background(), client, "rules-table")
+
+		t.Run("should return every rule sorted by order and name", func(t *testing.T) {
+			assert.NoError(t, err)
+			var names []string
+			for _, rule := range rules {
+				names = append(names, rule.Name)
+			}
+			assert.Equal(t, []string{"c", "a", "b"}, names)
+		})
+	})
+
+	t.Run("when Scan returns an error", func(t *testing.T) {
+		client := &mockDynamoDBClient{}
+		client.On("Scan", mock.Anything, mock.Anything).Return(nil, assert.AnError)
+
+		_, err := LoadDynamoDB(context.Background(), client, "rules-table")
+
+		t.Run("should return the error", func(t *testing.T) {
+			assert.ErrorIs(t, err, assert.AnError)
+		})
+	})
+}
@@ -0,0 +1,130 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/nivedita-verma/event-processor/pkg/eventspec"
+)
+
+// Action is what a rule does to the events it matches.
+type Action string
+
+const (
+	// ActionTag adds the rule's value to the event's tags.
+	ActionTag Action = "tag"
+	// ActionDrop acknowledges the event without routing it.
+	ActionDrop Action = "drop"
+	// ActionRoute sends the event to the route named by the rule's value.
+	ActionRoute Action = "route"
+	// ActionSetPriority sets the event's priority to the rule's value.
+	ActionSetPriority Action = "set-priority"
+)
+
+var priorities = []eventspec.Priority{
+	eventspec.PriorityCritical,
+	eventspec.PriorityHigh,
+	eventspec.PriorityNormal,
+	eventspec.PriorityLow,
+}
+
+// Rule applies an action to the events matching its condition. Rules are
+// applied in order; Order only sorts rules loaded from a table, where they
+// have no order of their own.
+type Rule struct {
+	Name   string `json:"name" yaml:"name" dynamodbav:"Name"`
+	When   string `json:"when" yaml:"when" dynamodbav:"When"`
+	Action Action `json:"action" yaml:"action" dynamodbav:"Action"`
+	Value  string `json:"value,omitempty" yaml:"value,omitempty" dynamodbav:"Value,omitempty"`
+	Order  int    `json:"-" yaml:"-" dynamodbav:"Order"`
+}
+
+type compiledRule struct {
+	Rule
+	condition *Expression
+}
+
+// RuleSet is an ordered list of compiled rules.
+type RuleSet struct {
+	rules []compiledRule
+	// routes are the routes route rules may name, or nil if any route may be
+	// named.
+	routes []string
+}
+
+type RuleSetOption func(*RuleSet)
+
+// WithRoutes only allows route rules to name the given routes, so that rules
+// naming a route that is not registered fail to load rather than sending
+// events to the default route.
+func WithRoutes(routes ...string) RuleSetOption {
+	return func(s *RuleSet) {
+		s.routes = append([]string{}, routes...)
+	}
+}
+
+// NewRuleSet compiles and checks the rules, reporting every invalid rule.
+func NewRuleSet(rules []Rule, opts ...RuleSetOption) (*RuleSet, error) {
+	set := &RuleSet{rules: make([]compiledRule, 0, len(rules))}
+	for _, opt := range opts {
+		opt(set)
+	}
+	var errs []error
+	for i, rule := range rules {
+		compiled, err := set.compile(rule)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("rule %d (%s): %w", i+1, rule.Name, err))
+			continue
+		}
+		set.rules = append(set.rules, compiled)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return set, nil
+}
+
+func (s *RuleSet) compile(rule Rule) (compiledRule, error) {
+	if rule.Name == "" {
+		return compiledRule{}, errors.New("name must not be empty")
+	}
+	switch rule.Action {
+	case ActionDrop:
+	case ActionTag, ActionRoute:
+		if rule.Value == "" {
+			return compiledRule{}, fmt.Errorf("action %s needs a value", rule.Action)
+		}
+		if rule.Action == ActionRoute && s.routes != nil && !slices.Contains(s.routes, rule.Value) {
+			return compiledRule{}, fmt.Errorf("unknown route %q, expected one of %v", rule.Value, s.routes)
+		}
+	case ActionSetPriority:
+		if !slices.Contains(priorities, eventspec.Priority(rule.Value)) {
+			return compiledRule{}, fmt.Errorf("invalid priority %q, expected one of %v", rule.Value, priorities)
+		}
+	default:
+		return compiledRule{}, fmt.Errorf("unknown action %q", rule.Action)
+	}
+	condition, err := Compile(rule.When)
+	if err != nil {
+		return compiledRule{}, err
+	}
+	return compiledRule{Rule: rule, condition: condition}, nil
+}
+
+// Match returns the rules whose conditions hold for the event, in order.
+func (s *RuleSet) Match(event eventspec.Event) []Rule {
+	fields := map[string]interface{}{
+		"eventId":  event.EventID,
+		"clientId": event.ClientID,
+		"type":     event.Type,
+		"data":     event.Data,
+	}
+	var matched []Rule
+	for _, rule := range s.rules {
+		if rule.condition.Match(fields) {
+			matched = append(matched, rule.Rule)
+		}
+	}
+	return matched
+}
@@ -0,0 +1,85 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/nivedita-verma/event-processor/pkg/eventspec"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewRuleSet(t *testing.T) {
+	t.Run("when every rule is valid", func(t *testing.T) {
+		_, err := NewRuleSet([]Rule{
+			{Name: "drop-tests", When: `clientId == "test"`, Action: ActionDrop},
+			{Name: "tag-gbp", When: `data.currency == "GBP"`, Action: ActionTag, Value: "gbp"},
+			{Name: "review", When: `data.amount > 5000`, Action: ActionRoute, Value: "review"},
+			{Name: "urgent", When: `data.severity == "critical"`, Action: ActionSetPriority, Value: "critical"},
+		})
+
+		t.Run("should complete without error", func(t *testing.T) {
+			assert.NoError(t, err)
+		})
+	})
+
+	t.Run("when rules are invalid", func(t *testing.T) {
+		_, err := NewRuleSet([]Rule{
+			{Name: "", When: `true`, Action: ActionDrop},
+			{Name: "no-value", When: `true`, Action: ActionTag},
+			{Name: "bad-priority", When: `true`, Action: ActionSetPriority, Value: "urgent"},
+			{Name: "bad-action", When: `true`, Action: "delete"},
+			{Name: "bad-condition", When: `type ==`, Action: ActionDrop},
+		})
+
+		t.Run("should report every invalid rule", func(t *testing.T) {
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), "rule 1 (): name must not be empty")
+				assert.Contains(t, err.Error(), "rule 2 (no-value): action tag needs a value")
+				assert.Contains(t, err.Error(), `rule 3 (bad-priority): invalid priority "urgent"`)
+				assert.Contains(t, err.Error(), `rule 4 (bad-action): unknown action "delete"`)
+				assert.Contains(t, err.Error(), `rule 5 (bad-condition): invalid condition "type =="`)
+			}
+		})
+	})
+
+	t.Run("when routes are given", func(t *testing.T) {
+		_, err := NewRuleSet([]Rule{
+			{Name: "fast", When: `data.amount > 5000`, Action: ActionRoute, Value: "fastPath"},
+			{Name: "review", When: `data.amount > 5000`, Action: ActionRoute, Value: "review"},
+		}, WithRoutes("fastPath", "transaction"))
+
+		t.Run("should report rules routing to an unknown route", func(t *testing.T) {
+			if assert.Error(t, err) {
+				assert.Equal(t, `rule 2 (review): unknown route "review", expected one of [fastPath transaction]`, err.Error())
+			}
+		})
+	})
+
+	t.Run("when no routes are registered", func(t *testing.T) {
+		_, err := NewRuleSet([]Rule{
+			{Name: "review", When: `true`, Action: ActionRoute, Value: "review"},
+		}, WithRoutes())
+
+		t.Run("should report every route rule", func(t *testing.T) {
+			assert.ErrorContains(t, err, `rule 1 (review): unknown route "review"`)
+		})
+	})
+}
+
+func Test_RuleSet_Match(t *testing.T) {
+	ruleSet, err := NewRuleSet([]Rule{
+		{Name: "large", When: `type == "transaction" && data.amount > 10000`, Action: ActionSetPriority, Value: "high"},
+		{Name: "alerts", When: `type == "monitoringAlert"`, Action: ActionRoute, Value: "alerts"},
+		{Name: "gbp", When: `data.currency == "GBP"`, Action: ActionTag, Value: "gbp"},
+	})
+	assert.NoError(t, err)
+
+	matched := ruleSet.Match(eventspec.Event{EventID: "1", ClientID: "client-1", Type: "transaction", Data: map[string]interface{}{"amount": 20000.0, "currency": "GBP"}})
+
+	t.Run("should return the matching rules in order", func(t *testing.T) {
+		var names []string
+		for _, rule := range matched {
+			names = append(names, rule.Name)
+		}
+		assert.Equal(t, []string{"large", "gbp"}, names)
+	})
+}
@@ -0,0 +1,46 @@
+// Package signing provides the keys that verify the signatures of each
+// client's events.
+package signing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nivedita-verma/event-processor/internal/pkg/clientregistry"
+	"github.com/nivedita-verma/event-processor/pkg/eventspec"
+)
+
+// ErrKeyNotFound is returned when a client has no key to verify its
+// signatures.
+var ErrKeyNotFound = errors.New("signing key not found")
+
+// KeyProvider returns the key verifying a client's signatures, or
+// ErrKeyNotFound.
+type KeyProvider interface {
+	Key(ctx context.Context, clientID string) (eventspec.VerificationKey, error)
+}
+
+// RegistryKeyProvider reads the keys stored with clients in the client
+// registry, so a key is rotated by updating the client.
+type RegistryKeyProvider struct {
+	registry clientregistry.Api
+}
+
+func NewRegistryKeyProvider(registry clientregistry.Api) *RegistryKeyProvider {
+	return &RegistryKeyProvider{registry: registry}
+}
+
+func (p *RegistryKeyProvider) Key(ctx context.Context, clientID string) (eventspec.VerificationKey, error) {
+	client, err := p.registry.Get(ctx, clientID)
+	if errors.Is(err, clientregistry.ErrClientNotFound) {
+		return eventspec.VerificationKey{}, fmt.Errorf("client %s: %w", clientID, ErrKeyNotFound)
+	}
+	if err != nil {
+		return eventspec.VerificationKey{}, err
+	}
+	if client.SigningKey == nil {
+		return eventspec.VerificationKey{}, fmt.Errorf("client %s: %w", clientID, ErrKeyNotFound)
+	}
+	return *client.SigningKey, nil
+}
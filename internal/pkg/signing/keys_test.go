@@ -0,0 +1,58 @@
+package signing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nivedita-verma/event-processor/internal/pkg/clientregistry"
+	"github.com/nivedita-verma/event-processor/pkg/eventspec"
+	"github.com/stretchr/testify/assert"
+)
+
+type failingRegistry struct{}
+
+func (failingRegistry) Get(context.Context, string) (clientregistry.Client, error) {
+	return clientregistry.Client{}, assert.AnError
+}
+
+func Test_RegistryKeyProvider(t *testing.T) {
+	key := eventspec.VerificationKey{Algorithm: eventspec.SignatureHMACSHA256, Key: []byte("secret")}
+	provider := NewRegistryKeyProvider(clientregistry.NewMemoryRegistry(
+		clientregistry.Client{ClientID: "client-1", SigningKey: &key},
+		clientregistry.Client{ClientID: "client-2"},
+	))
+
+	t.Run("when the client has a key", func(t *testing.T) {
+		result, err := provider.Key(context.Background(), "client-1")
+
+		t.Run("should return it", func(t *testing.T) {
+			assert.NoError(t, err)
+			assert.Equal(t, key, result)
+		})
+	})
+
+	t.Run("when the client has no key", func(t *testing.T) {
+		_, err := provider.Key(context.Background(), "client-2")
+
+		t.Run("should return ErrKeyNotFound", func(t *testing.T) {
+			assert.ErrorIs(t, err, ErrKeyNotFound)
+		})
+	})
+
+	t.Run("when the client is unknown", func(t *testing.T) {
+		_, err := provider.Key(context.Background(), "unknown")
+
+		t.Run("should return ErrKeyNotFound", func(t *testing.T) {
+			assert.ErrorIs(t, err, ErrKeyNotFound)
+		})
+	})
+
+	t.Run("when the registry fails", func(t *testing.T) {
+		_, err := NewRegistryKeyProvider(failingRegistry{}).Key(context.Background(), "client-1")
+
+		t.Run("should return the error", func(t *testing.T) {
+			assert.ErrorIs(t, err, assert.AnError)
+			assert.NotErrorIs(t, err, ErrKeyNotFound)
+		})
+	})
+}
@@ -1,5 +1,32 @@
 package vars
 
 const (
-	TableNameEnvVar = "EVENTS_TABLE_NAME"
+	TableNameEnvVar              = "EVENTS_TABLE_NAME"
+	QuarantineTableNameEnvVar    = "QUARANTINE_TABLE_NAME"
+	EnvelopeValidationModeEnvVar = "ENVELOPE_VALIDATION_MODE"
+	ProcessingConcurrencyEnvVar  = "PROCESSING_CONCURRENCY"
+	OrderByClientEnvVar          = "ORDER_BY_CLIENT"
+	DetectConflictsEnvVar        = "DETECT_CONFLICTING_DUPLICATES"
+	BatchWritesEnvVar            = "BATCH_WRITES"
+	RouteTablesEnvVar            = "ROUTE_TABLES"
+	PipelineStagesEnvVar         = "PIPELINE_STAGES"
+	FastPathQueueURLEnvVar       = "FAST_PATH_QUEUE_URL"
+	RulesTableEnvVar             = "RULES_TABLE"
+	RulesFileEnvVar              = "RULES_FILE"
+	ClientTableEnvVar            = "CLIENT_TABLE_NAME"
+	ClientCacheTTLEnvVar         = "CLIENT_CACHE_TTL"
+	ClientChecksEnvVar           = "CLIENT_CHECKS"
+	SignatureVerificationEnvVar  = "SIGNATURE_VERIFICATION"
+	QuotaTableEnvVar             = "QUOTA_TABLE_NAME"
+	QuotaRateEnvVar              = "QUOTA_RATE"
+	QuotaBurstEnvVar             = "QUOTA_BURST"
+	QuotaPerTypeEnvVar           = "QUOTA_PER_TYPE"
+	QuotaModeEnvVar              = "QUOTA_MODE"
+	RedactionPolicyEnvVar        = "REDACTION_POLICY"
+	FieldEncryptionKeyEnvVar     = "FIELD_ENCRYPTION_KEY_ID"
+	EncryptionPolicyEnvVar       = "ENCRYPTION_POLICY"
+	PollQueueURLEnvVar           = "POLL_QUEUE_URL"
+	HTTPAddrEnvVar               = "HTTP_ADDR"
+	IngressQueueURLEnvVar        = "INGRESS_QUEUE_URL"
+	LambdaEventSourceEnvVar      = "LAMBDA_EVENT_SOURCE"
 )
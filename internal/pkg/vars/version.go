@@ -0,0 +1,5 @@
+package vars
+
+// Version identifies the build of the processor. It is set at build time with
+// -ldflags "-X github.com/nivedita-verma/event-processor/internal/pkg/vars.Version=<version>".
+var Version = "dev"
@@ -0,0 +1,96 @@
+package eventspec
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrorCode is a stable, machine-readable identifier for the cause of a
+// validation failure. Codes are part of the public contract and must not be
+// renamed once released.
+type ErrorCode string
+
+const (
+	CodeEmptyBody       ErrorCode = "EMPTY_BODY"
+	CodeMalformedJSON   ErrorCode = "MALFORMED_JSON"
+	CodeMissingField    ErrorCode = "MISSING_FIELD"
+	CodeInvalidValue    ErrorCode = "INVALID_VALUE"
+	CodeUnknownField    ErrorCode = "UNKNOWN_FIELD"
+	CodeUnsupportedType ErrorCode = "UNSUPPORTED_TYPE"
+	// CodeUnknownClient and CodeClientSuspended reject events of clients that
+	// were never onboarded or are no longer active.
+	CodeUnknownClient   ErrorCode = "UNKNOWN_CLIENT"
+	CodeClientSuspended ErrorCode = "CLIENT_SUSPENDED"
+	// CodeTypeNotAllowed rejects events of a type the client may not send.
+	CodeTypeNotAllowed ErrorCode = "TYPE_NOT_ALLOWED"
+	// CodeQuotaExceeded rejects events over their client's quota.
+	CodeQuotaExceeded ErrorCode = "QUOTA_EXCEEDED"
+	// CodeMissingSignature and CodeInvalidSignature reject events that are not
+	// signed, or whose signature does not verify with their client's key.
+	CodeMissingSignature ErrorCode = "MISSING_SIGNATURE"
+	CodeInvalidSignature ErrorCode = "INVALID_SIGNATURE"
+)
+
+// ValidationError describes a single reason an event was rejected. Pointer is
+// the JSON pointer of the offending field within the event, e.g. "/data/amount",
+// and is empty when the failure concerns the event as a whole.
+type ValidationError struct {
+	Code    ErrorCode `json:"code"`
+	Pointer string    `json:"pointer,omitempty"`
+	Message string    `json:"message"`
+}
+
+func (e ValidationError) Error() string {
+	if e.Pointer == "" {
+		return fmt.Sprintf("%s: %s", e.Code, e.Message)
+	}
+	return fmt.Sprintf("%s %s: %s", e.Code, e.Pointer, e.Message)
+}
+
+// ValidationErrors collects every validation failure found for one event.
+type ValidationErrors []ValidationError
+
+func (errs ValidationErrors) Error() string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// WithCode returns the errors carrying the given code.
+func (errs ValidationErrors) WithCode(code ErrorCode) ValidationErrors {
+	var matched ValidationErrors
+	for _, err := range errs {
+		if err.Code == code {
+			matched = append(matched, err)
+		}
+	}
+	return matched
+}
+
+// WithoutCode returns the errors not carrying the given code.
+func (errs ValidationErrors) WithoutCode(code ErrorCode) ValidationErrors {
+	var matched ValidationErrors
+	for _, err := range errs {
+		if err.Code != code {
+			matched = append(matched, err)
+		}
+	}
+	return matched
+}
+
+// AsValidationErrors extracts the validation errors from err. Errors that are
+// not validation errors yield nil.
+func AsValidationErrors(err error) ValidationErrors {
+	var errs ValidationErrors
+	if errors.As(err, &errs) {
+		return errs
+	}
+	var single ValidationError
+	if errors.As(err, &single) {
+		return ValidationErrors{single}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package eventspec
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ValidationErrors_Error(t *testing.T) {
+	errs := ValidationErrors{
+		{Code: CodeEmptyBody, Message: "empty message body"},
+		{Code: CodeMissingField, Pointer: "/data/amount", Message: "missing required property"},
+	}
+
+	t.Run("should join each error with its code and pointer", func(t *testing.T) {
+		assert.Equal(t, "EMPTY_BODY: empty message body; MISSING_FIELD /data/amount: missing required property", errs.Error())
+	})
+}
+
+func Test_ValidationErrors_WithCode(t *testing.T) {
+	missing := ValidationError{Code: CodeMissingField, Pointer: "/eventId"}
+	unknown := ValidationError{Code: CodeUnknownField, Pointer: "/clientID"}
+	errs := ValidationErrors{missing, unknown}
+
+	t.Run("should return only errors with the code", func(t *testing.T) {
+		assert.Equal(t, ValidationErrors{unknown}, errs.WithCode(CodeUnknownField))
+	})
+
+	t.Run("should return only errors without the code", func(t *testing.T) {
+		assert.Equal(t, ValidationErrors{missing}, errs.WithoutCode(CodeUnknownField))
+	})
+}
+
+func Test_AsValidationErrors(t *testing.T) {
+	t.Run("when error wraps validation errors", func(t *testing.T) {
+		errs := ValidationErrors{{Code: CodeMissingField, Pointer: "/eventId"}}
+
+		t.Run("should return them", func(t *testing.T) {
+			assert.Equal(t, errs, AsValidationErrors(fmt.Errorf("wrapped: %w", errs)))
+		})
+	})
+
+	t.Run("when error wraps a single validation error", func(t *testing.T) {
+		err := ValidationError{Code: CodeUnsupportedType, Pointer: "/type"}
+
+		t.Run("should return it as a list", func(t *testing.T) {
+			assert.Equal(t, ValidationErrors{err}, AsValidationErrors(fmt.Errorf("wrapped: %w", err)))
+		})
+	})
+
+	t.Run("when error is not a validation error", func(t *testing.T) {
+		t.Run("should return nil", func(t *testing.T) {
+			assert.Nil(t, AsValidationErrors(assert.AnError))
+			assert.Nil(t, AsValidationErrors(nil))
+		})
+	})
+}
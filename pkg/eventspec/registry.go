@@ -0,0 +1,166 @@
+package eventspec
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+//go:embed schema/*.json
+var schemaFiles embed.FS
+
+var printer = message.NewPrinter(language.English)
+
+const envelopeSchemaPath = "schema/event.json"
+
+// SchemaRegistry holds the compiled JSON Schema for the event envelope and for
+// the `data` payload of each supported event type. Schemas are embedded from
+// schema/event.json and schema/<eventType>.json respectively.
+type SchemaRegistry struct {
+	envelope *jsonschema.Schema
+	schemas  map[EventType]*jsonschema.Schema
+}
+
+func NewSchemaRegistry() (*SchemaRegistry, error) {
+	compiler := jsonschema.NewCompiler()
+	envelope, err := compileSchema(compiler, envelopeSchemaPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load envelope schema: %w", err)
+	}
+
+	registry := &SchemaRegistry{
+		envelope: envelope,
+		schemas:  make(map[EventType]*jsonschema.Schema, len(ValidEventTypes)),
+	}
+	for _, eventType := range ValidEventTypes {
+		schema, err := compileSchema(compiler, fmt.Sprintf("schema/%s.json", eventType))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load schema for event type %s: %w", eventType, err)
+		}
+		registry.schemas[eventType] = schema
+	}
+	return registry, nil
+}
+
+func compileSchema(compiler *jsonschema.Compiler, path string) (*jsonschema.Schema, error) {
+	raw, err := schemaFiles.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	if err := compiler.AddResource(path, doc); err != nil {
+		return nil, err
+	}
+	return compiler.Compile(path)
+}
+
+// ValidateEnvelope checks a raw event body against the envelope schema.
+// Properties that the schema does not declare are reported with CodeUnknownField
+// so callers can choose whether to reject or tolerate them.
+func (r *SchemaRegistry) ValidateEnvelope(body []byte) error {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(body))
+	if err != nil {
+		return ValidationErrors{{Code: CodeMalformedJSON, Message: fmt.Sprintf("failed to parse message body: %v", err)}}
+	}
+	return validate(r.envelope, doc, "")
+}
+
+// Validate checks event.Data against the schema registered for event.Type.
+func (r *SchemaRegistry) Validate(event Event) error {
+	schema, ok := r.schemas[EventType(event.Type)]
+	if !ok {
+		return ValidationErrors{{Code: CodeUnsupportedType, Pointer: "/type", Message: fmt.Sprintf("unsupported event type: %s", event.Type)}}
+	}
+
+	// A nil map would otherwise be validated as an empty object
+	var data any
+	if event.Data != nil {
+		data = event.Data
+	}
+	return validate(schema, data, "/data")
+}
+
+// validate runs the schema against doc and converts any violations into
+// ValidationErrors whose pointers are prefixed with the location of doc within
+// the event.
+func validate(schema *jsonschema.Schema, doc any, prefix string) error {
+	err := schema.Validate(doc)
+	if err == nil {
+		return nil
+	}
+	validationErr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return err
+	}
+
+	var errs ValidationErrors
+	for _, leaf := range leafErrors(validationErr, nil) {
+		errs = append(errs, toValidationErrors(leaf, prefix)...)
+	}
+	return errs
+}
+
+// leafErrors flattens the validation error tree into its leaf causes.
+func leafErrors(err *jsonschema.ValidationError, collected []*jsonschema.ValidationError) []*jsonschema.ValidationError {
+	if len(err.Causes) == 0 {
+		return append(collected, err)
+	}
+	for _, cause := range err.Causes {
+		collected = leafErrors(cause, collected)
+	}
+	return collected
+}
+
+func toValidationErrors(err *jsonschema.ValidationError, prefix string) ValidationErrors {
+	location := prefix + jsonPointer(err.InstanceLocation)
+	switch errKind := err.ErrorKind.(type) {
+	case *kind.Required:
+		errs := make(ValidationErrors, len(errKind.Missing))
+		for i, missing := range errKind.Missing {
+			errs[i] = ValidationError{
+				Code:    CodeMissingField,
+				Pointer: location + "/" + escapePointerToken(missing),
+				Message: "missing required property",
+			}
+		}
+		return errs
+	case *kind.AdditionalProperties:
+		errs := make(ValidationErrors, len(errKind.Properties))
+		for i, property := range errKind.Properties {
+			errs[i] = ValidationError{
+				Code:    CodeUnknownField,
+				Pointer: location + "/" + escapePointerToken(property),
+				Message: "property is not declared by the schema",
+			}
+		}
+		return errs
+	default:
+		return ValidationErrors{{
+			Code:    CodeInvalidValue,
+			Pointer: location,
+			Message: err.ErrorKind.LocalizedString(printer),
+		}}
+	}
+}
+
+func jsonPointer(tokens []string) string {
+	var sb strings.Builder
+	for _, token := range tokens {
+		sb.WriteByte('/')
+		sb.WriteString(escapePointerToken(token))
+	}
+	return sb.String()
+}
+
+func escapePointerToken(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
@@ -0,0 +1,188 @@
+package eventspec
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewSchemaRegistry(t *testing.T) {
+	registry, err := NewSchemaRegistry()
+
+	t.Run("should complete without error", func(t *testing.T) {
+		assert.NoError(t, err)
+	})
+
+	t.Run("should register a schema for every valid event type", func(t *testing.T) {
+		for _, eventType := range ValidEventTypes {
+			assert.Contains(t, registry.schemas, eventType)
+		}
+	})
+}
+
+func Test_SchemaRegistry_Validate(t *testing.T) {
+	registry, err := NewSchemaRegistry()
+	assert.NoError(t, err)
+
+	t.Run("when data is valid for each event type", func(t *testing.T) {
+		validEvents := []Event{
+			{EventID: "1", ClientID: "client-1", Type: string(MonitoringAlert), Data: map[string]interface{}{"severity": "critical", "message": "disk full"}},
+			{EventID: "2", ClientID: "client-1", Type: string(Notification), Data: map[string]interface{}{"message": "hello", "channel": "email"}},
+			{EventID: "3", ClientID: "client-1", Type: string(Transaction), Data: map[string]interface{}{"amount": 12.5, "currency": "GBP"}},
+		}
+
+		t.Run("should complete without error", func(t *testing.T) {
+			for _, event := range validEvents {
+				assert.NoError(t, registry.Validate(event), event.Type)
+			}
+		})
+	})
+
+	t.Run("when required data fields are missing", func(t *testing.T) {
+		event := Event{EventID: "1", ClientID: "client-1", Type: string(Transaction), Data: map[string]interface{}{"status": "pending"}}
+
+		err := registry.Validate(event)
+
+		t.Run("should return a missing field error for each missing field", func(t *testing.T) {
+			assert.ElementsMatch(t, ValidationErrors{
+				{Code: CodeMissingField, Pointer: "/data/amount", Message: "missing required property"},
+				{Code: CodeMissingField, Pointer: "/data/currency", Message: "missing required property"},
+			}, AsValidationErrors(err))
+		})
+	})
+
+	t.Run("when a data field has the wrong value", func(t *testing.T) {
+		event := Event{EventID: "1", ClientID: "client-1", Type: string(MonitoringAlert), Data: map[string]interface{}{"severity": "P1", "message": "disk full"}}
+
+		errs := AsValidationErrors(registry.Validate(event))
+
+		t.Run("should return an invalid value error pointing at the field", func(t *testing.T) {
+			if assert.Len(t, errs, 1) {
+				assert.Equal(t, CodeInvalidValue, errs[0].Code)
+				assert.Equal(t, "/data/severity", errs[0].Pointer)
+			}
+		})
+	})
+
+	t.Run("when data is nil", func(t *testing.T) {
+		event := Event{EventID: "1", ClientID: "client-1", Type: string(Notification)}
+
+		errs := AsValidationErrors(registry.Validate(event))
+
+		t.Run("should return an invalid value error for data", func(t *testing.T) {
+			if assert.Len(t, errs, 1) {
+				assert.Equal(t, CodeInvalidValue, errs[0].Code)
+				assert.Equal(t, "/data", errs[0].Pointer)
+			}
+		})
+	})
+
+	t.Run("when event type has no schema", func(t *testing.T) {
+		event := Event{EventID: "1", ClientID: "client-1", Type: "Unknown", Data: map[string]interface{}{}}
+
+		err := registry.Validate(event)
+
+		t.Run("should return unsupported type error", func(t *testing.T) {
+			assert.Equal(t, ValidationErrors{
+				{Code: CodeUnsupportedType, Pointer: "/type", Message: "unsupported event type: Unknown"},
+			}, AsValidationErrors(err))
+		})
+	})
+}
+
+func Test_SchemaRegistry_ValidateEnvelope(t *testing.T) {
+	registry, err := NewSchemaRegistry()
+	assert.NoError(t, err)
+
+	t.Run("when body matches the envelope schema", func(t *testing.T) {
+		err := registry.ValidateEnvelope([]byte(`{"eventId":"1","clientId":"client-1","type":"notification","data":{}}`))
+
+		t.Run("should complete without error", func(t *testing.T) {
+			assert.NoError(t, err)
+		})
+	})
+
+	t.Run("when body has fields not declared by the schema", func(t *testing.T) {
+		err := registry.ValidateEnvelope([]byte(`{"eventId":"1","clientId":"client-1","clientID":"client-1","type":"notification","data":{}}`))
+
+		t.Run("should return unknown field error", func(t *testing.T) {
+			assert.Equal(t, ValidationErrors{
+				{Code: CodeUnknownField, Pointer: "/clientID", Message: "property is not declared by the schema"},
+			}, AsValidationErrors(err))
+		})
+	})
+
+	t.Run("when body violates the schema in several ways", func(t *testing.T) {
+		err := registry.ValidateEnvelope([]byte(`{"eventId":1,"type":"notification","data":{},"extra":true}`))
+
+		t.Run("should return every violation", func(t *testing.T) {
+			assert.ElementsMatch(t, ValidationErrors{
+				{Code: CodeMissingField, Pointer: "/clientId", Message: "missing required property"},
+				{Code: CodeInvalidValue, Pointer: "/eventId", Message: "got number, want string"},
+				{Code: CodeUnknownField, Pointer: "/extra", Message: "property is not declared by the schema"},
+			}, AsValidationErrors(err))
+		})
+	})
+
+	t.Run("when body is not JSON", func(t *testing.T) {
+		errs := AsValidationErrors(registry.ValidateEnvelope([]byte(`{"eventId":`)))
+
+		t.Run("should return malformed JSON error", func(t *testing.T) {
+			if assert.Len(t, errs, 1) {
+				assert.Equal(t, CodeMalformedJSON, errs[0].Code)
+			}
+		})
+	})
+}
+
+// Test_Event_MatchesEnvelopeSchema guards against the Event struct and
+// schema/event.json drifting apart.
+func Test_Event_MatchesEnvelopeSchema(t *testing.T) {
+	raw, err := schemaFiles.ReadFile(envelopeSchemaPath)
+	assert.NoError(t, err)
+
+	var schema struct {
+		Properties map[string]struct {
+			Type string `json:"type"`
+		} `json:"properties"`
+		Required []string `json:"required"`
+	}
+	assert.NoError(t, json.Unmarshal(raw, &schema))
+
+	jsonTypes := map[reflect.Kind]string{
+		reflect.String: "string",
+		reflect.Map:    "object",
+	}
+
+	eventType := reflect.TypeOf(Event{})
+	var structFields, structRequired []string
+	for i := 0; i < eventType.NumField(); i++ {
+		field := eventType.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		structFields = append(structFields, name)
+		if field.Tag.Get("validate") == "required" {
+			structRequired = append(structRequired, name)
+		}
+
+		t.Run("field "+name+" should have the same type in the schema", func(t *testing.T) {
+			if assert.Contains(t, schema.Properties, name) {
+				assert.Equal(t, jsonTypes[field.Type.Kind()], schema.Properties[name].Type)
+			}
+		})
+	}
+
+	t.Run("should declare the same properties", func(t *testing.T) {
+		schemaFields := make([]string, 0, len(schema.Properties))
+		for name := range schema.Properties {
+			schemaFields = append(schemaFields, name)
+		}
+		assert.ElementsMatch(t, schemaFields, structFields)
+	})
+
+	t.Run("should declare the same required properties", func(t *testing.T) {
+		assert.ElementsMatch(t, schema.Required, structRequired)
+	})
+}
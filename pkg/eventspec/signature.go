@@ -0,0 +1,111 @@
+package eventspec
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// SignatureAttribute and SignatureAlgorithmAttribute are the message
+// attributes carrying the base64 signature of a message body and the
+// algorithm it was made with.
+const (
+	SignatureAttribute          = "signature"
+	SignatureAlgorithmAttribute = "signatureAlgorithm"
+)
+
+// SignatureAlgorithm is how a message body is signed.
+type SignatureAlgorithm string
+
+const (
+	// SignatureHMACSHA256 signs with a secret shared by the client and the
+	// processor.
+	SignatureHMACSHA256 SignatureAlgorithm = "HMAC-SHA256"
+	// SignatureEd25519 signs with the client's private key, so the processor
+	// only holds its public key.
+	SignatureEd25519 SignatureAlgorithm = "Ed25519"
+)
+
+// ErrInvalidSignature is returned when a signature does not match the body it
+// was sent with.
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// Signer signs the bodies of the messages a producer sends.
+type Signer struct {
+	algorithm SignatureAlgorithm
+	sign      func(body []byte) []byte
+}
+
+// NewHMACSigner signs with HMAC-SHA256 under the client's shared secret.
+func NewHMACSigner(secret []byte) *Signer {
+	return &Signer{
+		algorithm: SignatureHMACSHA256,
+		sign: func(body []byte) []byte {
+			return hmacSHA256(secret, body)
+		},
+	}
+}
+
+// NewEd25519Signer signs with the client's Ed25519 private key.
+func NewEd25519Signer(privateKey ed25519.PrivateKey) *Signer {
+	return &Signer{
+		algorithm: SignatureEd25519,
+		sign: func(body []byte) []byte {
+			return ed25519.Sign(privateKey, body)
+		},
+	}
+}
+
+// Sign returns the message attributes to send along with the body, keyed by
+// attribute name. The body must be sent exactly as signed.
+func (s *Signer) Sign(body []byte) map[string]string {
+	return map[string]string{
+		SignatureAttribute:          base64.StdEncoding.EncodeToString(s.sign(body)),
+		SignatureAlgorithmAttribute: string(s.algorithm),
+	}
+}
+
+// VerificationKey verifies the signatures of one client: Key is the shared
+// secret for HMAC-SHA256, or the public key for Ed25519.
+type VerificationKey struct {
+	Algorithm SignatureAlgorithm
+	Key       []byte
+}
+
+// Verify checks a base64 signature, as made by Signer, over the body. A
+// signature made with an algorithm other than the key's is invalid, so an
+// Ed25519 public key can never be used as an HMAC secret.
+func (k VerificationKey) Verify(body []byte, algorithm SignatureAlgorithm, signature string) error {
+	if algorithm != k.Algorithm {
+		return fmt.Errorf("%w: expected algorithm %s but got %q", ErrInvalidSignature, k.Algorithm, algorithm)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("%w: not base64", ErrInvalidSignature)
+	}
+	var valid bool
+	switch k.Algorithm {
+	case SignatureHMACSHA256:
+		valid = hmac.Equal(decoded, hmacSHA256(k.Key, body))
+	case SignatureEd25519:
+		if len(k.Key) != ed25519.PublicKeySize {
+			return fmt.Errorf("invalid Ed25519 public key of %d bytes", len(k.Key))
+		}
+		valid = ed25519.Verify(k.Key, body, decoded)
+	default:
+		return fmt.Errorf("unsupported signature algorithm %q", k.Algorithm)
+	}
+	if !valid {
+		return fmt.Errorf("%w: signature does not match the body", ErrInvalidSignature)
+	}
+	return nil
+}
+
+func hmacSHA256(secret, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
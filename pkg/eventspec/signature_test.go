@@ -0,0 +1,63 @@
+package eventspec
+
+import (
+	"crypto/ed25519"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Signer(t *testing.T) {
+	body := []byte(`{"eventId":"1","clientId":"client-1","type":"notification","data":{}}`)
+
+	t.Run("when signing with HMAC-SHA256", func(t *testing.T) {
+		secret := []byte("shared-secret")
+		attributes := NewHMACSigner(secret).Sign(body)
+		key := VerificationKey{Algorithm: SignatureHMACSHA256, Key: secret}
+
+		t.Run("should name the algorithm", func(t *testing.T) {
+			assert.Equal(t, "HMAC-SHA256", attributes[SignatureAlgorithmAttribute])
+		})
+
+		t.Run("should verify with the secret", func(t *testing.T) {
+			assert.NoError(t, key.Verify(body, SignatureHMACSHA256, attributes[SignatureAttribute]))
+		})
+
+		t.Run("should not verify with another secret", func(t *testing.T) {
+			other := VerificationKey{Algorithm: SignatureHMACSHA256, Key: []byte("other-secret")}
+			assert.ErrorIs(t, other.Verify(body, SignatureHMACSHA256, attributes[SignatureAttribute]), ErrInvalidSignature)
+		})
+
+		t.Run("should not verify a changed body", func(t *testing.T) {
+			assert.ErrorIs(t, key.Verify(append(body, ' '), SignatureHMACSHA256, attributes[SignatureAttribute]), ErrInvalidSignature)
+		})
+	})
+
+	t.Run("when signing with Ed25519", func(t *testing.T) {
+		publicKey, privateKey, err := ed25519.GenerateKey(nil)
+		assert.NoError(t, err)
+		attributes := NewEd25519Signer(privateKey).Sign(body)
+		key := VerificationKey{Algorithm: SignatureEd25519, Key: publicKey}
+
+		t.Run("should verify with the public key", func(t *testing.T) {
+			assert.NoError(t, key.Verify(body, SignatureEd25519, attributes[SignatureAttribute]))
+		})
+
+		t.Run("should not verify a changed body", func(t *testing.T) {
+			assert.ErrorIs(t, key.Verify(append(body, ' '), SignatureEd25519, attributes[SignatureAttribute]), ErrInvalidSignature)
+		})
+
+		t.Run("should not verify an HMAC made with the public key", func(t *testing.T) {
+			forged := NewHMACSigner(publicKey).Sign(body)
+			assert.ErrorIs(t, key.Verify(body, SignatureHMACSHA256, forged[SignatureAttribute]), ErrInvalidSignature)
+		})
+	})
+
+	t.Run("when the signature is not base64", func(t *testing.T) {
+		key := VerificationKey{Algorithm: SignatureHMACSHA256, Key: []byte("shared-secret")}
+
+		t.Run("should be invalid", func(t *testing.T) {
+			assert.ErrorIs(t, key.Verify(body, SignatureHMACSHA256, "not base64!"), ErrInvalidSignature)
+		})
+	})
+}
@@ -23,3 +23,13 @@ func IsValidEventType(eventType string) bool {
 func (e *EventType) String() string {
 	return string(*e)
 }
+
+// Priority ranks how urgently an event should be delivered.
+type Priority string
+
+const (
+	PriorityCritical Priority = "critical"
+	PriorityHigh     Priority = "high"
+	PriorityNormal   Priority = "normal"
+	PriorityLow      Priority = "low"
+)